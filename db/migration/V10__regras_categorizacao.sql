@@ -0,0 +1,48 @@
+-- =========================================================
+-- TABELA: financeiro.regras_categorizacao
+-- =========================================================
+-- Regras usadas pelo importador para categorizar as transações.
+-- Os critérios nulos não restringem; a primeira regra ativa, por
+-- ordem de prioridade (menor primeiro), em que todos os critérios
+-- informados combinam define a categoria da transação.
+CREATE TABLE financeiro.regras_categorizacao (
+  id                UUID PRIMARY KEY,
+  nome              VARCHAR(100) NOT NULL,
+  prioridade        INTEGER NOT NULL DEFAULT 100,
+  ativa             BOOLEAN NOT NULL DEFAULT TRUE,
+
+  -- Critérios
+  titulo_regex      TEXT,           -- expressão regular (sintaxe Go/RE2) aplicada ao título
+  descricao_regex   TEXT,           -- expressão regular aplicada à descrição
+  documento         VARCHAR(14),    -- CNPJ ou CPF da contraparte, sem máscara
+  valor_min         NUMERIC(14,2),  -- valor absoluto mínimo, inclusive
+  valor_max         NUMERIC(14,2),  -- valor absoluto máximo, inclusive
+  conta_id          UUID REFERENCES financeiro.contas(id) ON DELETE RESTRICT,
+  tipo_operacao     VARCHAR(10),
+  CONSTRAINT ck_regras_tipo_operacao CHECK (tipo_operacao IN ('credito','debito')),
+  CONSTRAINT ck_regras_valor CHECK (valor_min IS NULL OR valor_max IS NULL OR valor_min <= valor_max),
+
+  -- Resultado
+  categoria         VARCHAR(60) NOT NULL,
+  subcategoria      VARCHAR(60),
+
+  criado_em         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  atualizado_em     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX ix_regras_categorizacao_prioridade ON financeiro.regras_categorizacao (prioridade) WHERE ativa;
+
+-- =========================================================
+-- Categoria das transações
+-- =========================================================
+-- regra_id nulo com categoria preenchida indica categorização
+-- manual, que não é alterada pela recategorização. Por isso uma regra
+-- em uso não pode ser apagada (a transação passaria a parecer manual):
+-- deve ser desativada, e a próxima recategorização refaz as transações.
+ALTER TABLE financeiro.transacoes
+  ADD COLUMN categoria     VARCHAR(60),
+  ADD COLUMN subcategoria  VARCHAR(60),
+  ADD COLUMN regra_id      UUID REFERENCES financeiro.regras_categorizacao(id) ON DELETE RESTRICT;
+
+CREATE INDEX ix_transacoes_categoria ON financeiro.transacoes (categoria);
+CREATE INDEX ix_transacoes_regra     ON financeiro.transacoes (regra_id);
@@ -0,0 +1,107 @@
+-- =========================================================
+-- TABELA: financeiro.plano_contas
+-- =========================================================
+-- Plano de contas gerencial de cada empresa, em árvore. O código
+-- segue a hierarquia ("4", "4.1", "4.1.01"): a conta pai de
+-- "4.1.01" é "4.1". natureza agrupa as contas nas linhas da DRE
+-- e é herdada das contas pai; contas nao_operacional (transferências
+-- internas, aportes, distribuição de lucros) ficam fora da DRE.
+CREATE TABLE financeiro.plano_contas (
+  id              UUID PRIMARY KEY,
+  empresa_id      UUID NOT NULL REFERENCES cadastros.empresas(id) ON DELETE RESTRICT,
+  parent_id       UUID REFERENCES financeiro.plano_contas(id) ON DELETE RESTRICT,
+  codigo          VARCHAR(20) NOT NULL,
+  nome            VARCHAR(100) NOT NULL,
+  natureza        VARCHAR(20) NOT NULL,
+  ativa           BOOLEAN NOT NULL DEFAULT TRUE,
+  criado_em       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  atualizado_em   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT uq_plano_contas_empresa_codigo UNIQUE (empresa_id, codigo),
+  CONSTRAINT ck_plano_contas_codigo CHECK (codigo ~ '^[0-9]+(\.[0-9]+)*$'),
+  CONSTRAINT ck_plano_contas_natureza CHECK (natureza IN ('receita','deducao','custo','despesa','nao_operacional'))
+);
+
+CREATE INDEX ix_plano_contas_parent ON financeiro.plano_contas (parent_id);
+
+-- Conta do plano da transação; as regras de categorização a definem
+-- pelo código, resolvido no plano da empresa titular da conta bancária
+ALTER TABLE financeiro.transacoes
+  ADD COLUMN plano_conta_id UUID REFERENCES financeiro.plano_contas(id) ON DELETE RESTRICT;
+
+CREATE INDEX ix_transacoes_plano_conta ON financeiro.transacoes (plano_conta_id);
+
+ALTER TABLE financeiro.regras_categorizacao
+  ADD COLUMN plano_conta_codigo VARCHAR(20);
+
+-- =========================================================
+-- Plano padrão: DRE de empresa de eventos no Simples Nacional
+-- =========================================================
+-- Cria as contas que a empresa ainda não tem e retorna quantas
+-- foram criadas. Usada abaixo para as empresas cadastradas e pelo
+-- comando "chart seed" do importador para empresas novas.
+CREATE FUNCTION financeiro.seed_plano_contas(p_empresa_id UUID) RETURNS INTEGER
+LANGUAGE plpgsql AS $$
+DECLARE
+  inseridas INTEGER;
+BEGIN
+  INSERT INTO financeiro.plano_contas (id, empresa_id, codigo, nome, natureza)
+  SELECT gen_random_uuid(), p_empresa_id, s.codigo, s.nome, s.natureza
+  FROM (VALUES
+    ('1',      'Receita bruta',                               'receita'),
+    ('1.1',    'Receita de serviços',                         'receita'),
+    ('1.1.01', 'Produção e organização de eventos',           'receita'),
+    ('1.1.02', 'Ações promocionais e promotores',             'receita'),
+    ('1.1.03', 'Locação de estruturas e equipamentos',        'receita'),
+    ('1.2',    'Outras receitas operacionais',                'receita'),
+    ('2',      'Deduções da receita',                         'deducao'),
+    ('2.1',    'Simples Nacional (DAS)',                      'deducao'),
+    ('2.2',    'ISS retido',                                  'deducao'),
+    ('2.3',    'Cancelamentos e devoluções',                  'deducao'),
+    ('3',      'Custos dos serviços prestados',               'custo'),
+    ('3.1',    'Mão de obra de eventos (freelancers)',        'custo'),
+    ('3.2',    'Fornecedores de eventos',                     'custo'),
+    ('3.3',    'Locação de espaços e equipamentos',           'custo'),
+    ('3.4',    'Transporte, hospedagem e alimentação',        'custo'),
+    ('3.5',    'Materiais e brindes',                         'custo'),
+    ('4',      'Despesas operacionais',                       'despesa'),
+    ('4.1',    'Despesas administrativas',                    'despesa'),
+    ('4.1.01', 'Aluguel e condomínio',                        'despesa'),
+    ('4.1.02', 'Contabilidade',                               'despesa'),
+    ('4.1.03', 'Softwares e assinaturas',                     'despesa'),
+    ('4.1.04', 'Telefonia e internet',                        'despesa'),
+    ('4.2',    'Despesas com pessoal',                        'despesa'),
+    ('4.2.01', 'Pró-labore',                                  'despesa'),
+    ('4.2.02', 'Salários e encargos',                         'despesa'),
+    ('4.2.03', 'Benefícios',                                  'despesa'),
+    ('4.3',    'Despesas comerciais',                         'despesa'),
+    ('4.3.01', 'Marketing e publicidade',                     'despesa'),
+    ('4.3.02', 'Comissões',                                   'despesa'),
+    ('4.4',    'Despesas financeiras',                        'despesa'),
+    ('4.4.01', 'Tarifas bancárias',                           'despesa'),
+    ('4.4.02', 'Juros e multas',                              'despesa'),
+    ('5',      'Receitas financeiras',                        'receita'),
+    ('5.1',    'Rendimentos de aplicações',                   'receita'),
+    ('6',      'Movimentações não operacionais',              'nao_operacional'),
+    ('6.1',    'Transferências internas',                     'nao_operacional'),
+    ('6.2',    'Aportes de sócios',                           'nao_operacional'),
+    ('6.3',    'Distribuição de lucros',                      'nao_operacional'),
+    ('6.4',    'Empréstimos e financiamentos',                'nao_operacional')
+  ) AS s(codigo, nome, natureza)
+  ON CONFLICT (empresa_id, codigo) DO NOTHING;
+  GET DIAGNOSTICS inseridas = ROW_COUNT;
+
+  UPDATE financeiro.plano_contas f
+  SET parent_id = p.id
+  FROM financeiro.plano_contas p
+  WHERE f.empresa_id = p_empresa_id
+    AND p.empresa_id = p_empresa_id
+    AND f.parent_id IS NULL
+    AND p.codigo = regexp_replace(f.codigo, '\.[0-9]+$', '')
+    AND p.codigo <> f.codigo;
+
+  RETURN inseridas;
+END;
+$$;
+
+SELECT financeiro.seed_plano_contas(id) FROM cadastros.empresas;
@@ -0,0 +1,31 @@
+-- =========================================================
+-- TABELA: financeiro.contrapartes
+-- =========================================================
+-- Clientes, fornecedores e demais contrapartes das transferências e
+-- pagamentos, extraídos pelo importador da descrição das transações.
+--
+-- chave identifica a contraparte entre extratos: o CPF/CNPJ completo
+-- ou, sem ele, o nome normalizado (e o CPF mascarado, quando houver).
+-- Os dados bancários são os da última transação importada.
+CREATE TABLE financeiro.contrapartes (
+  id              UUID PRIMARY KEY,
+  chave           VARCHAR(200) NOT NULL,
+  nome            VARCHAR(150) NOT NULL,
+  documento       VARCHAR(14),   -- CPF ou CNPJ sem máscara; dígitos ocultos de CPF mascarado como '*'
+  banco_nome      VARCHAR(100),
+  banco_codigo    VARCHAR(5),    -- código COMPE
+  ispb            CHAR(8),
+  agencia         VARCHAR(20),
+  conta           VARCHAR(30),
+  criado_em       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  atualizado_em   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT uq_contrapartes_chave UNIQUE (chave)
+);
+
+CREATE INDEX ix_contrapartes_documento ON financeiro.contrapartes (documento);
+
+ALTER TABLE financeiro.transacoes
+  ADD COLUMN contraparte_id UUID REFERENCES financeiro.contrapartes(id) ON DELETE SET NULL;
+
+CREATE INDEX ix_transacoes_contraparte ON financeiro.transacoes (contraparte_id);
@@ -0,0 +1,23 @@
+-- =========================================================
+-- TABELA: financeiro.transferencias_internas
+-- =========================================================
+-- Transferências entre as contas das nossas empresas: o débito em uma
+-- conta e o crédito correspondente na outra. Os pares são encontrados
+-- pelo comando "transfers" do importador (mesmo valor, datas próximas
+-- e contraparte com o CNPJ ou o nome da empresa da outra conta) e
+-- ficam fora de fct_transacoes, para não inflar receitas e despesas.
+--
+-- Remover uma transação não desfaz em silêncio a transferência de que
+-- ela participa: o comando "revert" lista as transferências da
+-- importação e só as remove com -force, antes das transações.
+CREATE TABLE financeiro.transferencias_internas (
+  id              UUID PRIMARY KEY,
+  debito_id       UUID NOT NULL REFERENCES financeiro.transacoes(id) ON DELETE RESTRICT,
+  credito_id      UUID NOT NULL REFERENCES financeiro.transacoes(id) ON DELETE RESTRICT,
+  criado_em       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  -- Cada transação participa de no máximo uma transferência
+  CONSTRAINT uq_transferencias_debito UNIQUE (debito_id),
+  CONSTRAINT uq_transferencias_credito UNIQUE (credito_id),
+  CONSTRAINT ck_transferencias_par CHECK (debito_id <> credito_id)
+);
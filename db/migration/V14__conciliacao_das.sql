@@ -0,0 +1,11 @@
+-- =========================================================
+-- CONCILIAÇÃO DOS DOCUMENTOS DAS
+-- =========================================================
+-- Débito bancário que pagou o documento. Preenchido pelo comando
+-- "reconcile" do importador, junto com o status PAGO; documentos
+-- vencidos sem pagamento passam a VENCIDO.
+ALTER TABLE financeiro.das_documentos
+  ADD COLUMN transacao_id UUID REFERENCES financeiro.transacoes(id) ON DELETE SET NULL,
+  ADD CONSTRAINT uq_das_transacao UNIQUE (transacao_id);
+
+CREATE INDEX ix_das_vencimento ON financeiro.das_documentos (data_vencimento);
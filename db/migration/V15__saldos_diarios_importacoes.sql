@@ -0,0 +1,28 @@
+-- =========================================================
+-- TABELA: financeiro.saldos_diarios_importacoes
+-- =========================================================
+-- Saldo de fim de dia informado pelo extrato de cada importação.
+-- financeiro.saldos_diarios guarda só a última importação de cada
+-- dia; ao reverter uma importação, o comando "revert" usa esta tabela
+-- para devolver ao dia o saldo das importações que continuam valendo
+-- e só remove os dias que nenhuma outra importação cobre.
+CREATE TABLE financeiro.saldos_diarios_importacoes (
+  conta_id          UUID NOT NULL REFERENCES financeiro.contas(id) ON DELETE RESTRICT,
+  data              DATE NOT NULL,
+  importacao_id     UUID NOT NULL REFERENCES financeiro.importacoes(id) ON DELETE RESTRICT,
+
+  -- Nulo quando o extrato não traz saldo
+  saldo_extrato     NUMERIC(14,2),
+
+  criado_em         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT pk_saldos_diarios_importacoes PRIMARY KEY (conta_id, data, importacao_id)
+);
+
+CREATE INDEX ix_saldos_diarios_importacoes_importacao ON financeiro.saldos_diarios_importacoes (importacao_id);
+
+-- Dos dias já gravados só se conhece a última importação
+INSERT INTO financeiro.saldos_diarios_importacoes (conta_id, data, importacao_id, saldo_extrato, criado_em)
+SELECT conta_id, data, importacao_id, saldo_extrato, atualizado_em
+FROM financeiro.saldos_diarios
+WHERE importacao_id IS NOT NULL;
@@ -0,0 +1,8 @@
+-- =========================================================
+-- financeiro.contas: busca pelo número só com os dígitos
+-- =========================================================
+-- O importador encontra a conta do extrato comparando só os dígitos
+-- do número ("12345678-9" e "123456789" são a mesma conta), tanto na
+-- importação quanto nos filtros -account dos comandos. A expressão
+-- deve ser a mesma de contaNumeroDigitos (importador_extrato/db/db.go).
+CREATE INDEX ix_contas_numero_digitos ON financeiro.contas (regexp_replace(numero, '\D', '', 'g'));
@@ -0,0 +1,49 @@
+-- =========================================================
+-- TABELA: financeiro.importacoes
+-- =========================================================
+-- Cada arquivo processado pelo importador gera um registro,
+-- inclusive os que falharam, para rastrear a origem dos dados.
+CREATE TABLE financeiro.importacoes (
+  id                UUID PRIMARY KEY,
+
+  arquivo_nome      TEXT NOT NULL,
+  arquivo_sha256    CHAR(64) NOT NULL,
+
+  -- Nome do parser utilizado (Parser.GetName()); nulo se nenhum parser reconheceu o arquivo
+  parser            VARCHAR(60),
+
+  conta_id          UUID REFERENCES financeiro.contas(id) ON DELETE RESTRICT,
+  empresa_id        UUID REFERENCES cadastros.empresas(id) ON DELETE RESTRICT,
+
+  -- Status textual controlado
+  status            VARCHAR(20) NOT NULL DEFAULT 'EM_ANDAMENTO',
+  CONSTRAINT ck_importacoes_status CHECK (status IN ('EM_ANDAMENTO', 'CONCLUIDA', 'FALHA')),
+  mensagem_erro     TEXT,
+
+  qtd_importadas    INTEGER NOT NULL DEFAULT 0,
+  qtd_ignoradas     INTEGER NOT NULL DEFAULT 0,
+  qtd_falhas        INTEGER NOT NULL DEFAULT 0,
+
+  iniciado_em       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  finalizado_em     TIMESTAMPTZ,
+
+  criado_em         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  atualizado_em     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX ix_importacoes_sha256 ON financeiro.importacoes (arquivo_sha256);
+CREATE INDEX ix_importacoes_status ON financeiro.importacoes (status);
+
+-- =========================================================
+-- Origem das transações e documentos DAS
+-- =========================================================
+-- Nulo para registros importados antes da criação desta tabela
+ALTER TABLE financeiro.transacoes
+  ADD COLUMN importacao_id UUID REFERENCES financeiro.importacoes(id) ON DELETE RESTRICT;
+
+CREATE INDEX ix_transacoes_importacao ON financeiro.transacoes (importacao_id);
+
+ALTER TABLE financeiro.das_documentos
+  ADD COLUMN importacao_id UUID REFERENCES financeiro.importacoes(id) ON DELETE RESTRICT;
+
+CREATE INDEX ix_das_importacao ON financeiro.das_documentos (importacao_id);
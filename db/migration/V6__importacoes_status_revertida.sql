@@ -0,0 +1,10 @@
+-- =========================================================
+-- financeiro.importacoes: status REVERTIDA
+-- =========================================================
+-- Importações desfeitas pelo comando "revert" continuam registradas
+-- para manter o histórico, mas sem transações/documentos associados.
+ALTER TABLE financeiro.importacoes DROP CONSTRAINT ck_importacoes_status;
+
+ALTER TABLE financeiro.importacoes
+  ADD CONSTRAINT ck_importacoes_status
+  CHECK (status IN ('EM_ANDAMENTO', 'CONCLUIDA', 'FALHA', 'REVERTIDA'));
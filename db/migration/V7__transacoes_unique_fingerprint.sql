@@ -0,0 +1,23 @@
+-- =========================================================
+-- financeiro.transacoes: unicidade por conta + fingerprint
+-- =========================================================
+-- Permite que o importador use INSERT ... ON CONFLICT DO NOTHING
+-- para descartar transações já importadas, sem consulta prévia.
+--
+-- A verificação anterior comparava o valor em ponto flutuante e podia
+-- deixar passar a mesma transação duas vezes. Antes de criar o índice,
+-- cada (conta_id, fingerprint) repetido fica só com a transação gravada
+-- primeiro. Para conferir o que será removido:
+--
+--   SELECT conta_id, fingerprint, COUNT(*)
+--   FROM financeiro.transacoes
+--   GROUP BY conta_id, fingerprint
+--   HAVING COUNT(*) > 1;
+DELETE FROM financeiro.transacoes t
+USING financeiro.transacoes k
+WHERE t.conta_id = k.conta_id
+  AND t.fingerprint = k.fingerprint
+  AND (t.criado_em, t.id) > (k.criado_em, k.id);
+
+CREATE UNIQUE INDEX uq_transacoes_conta_fingerprint
+  ON financeiro.transacoes (conta_id, fingerprint);
@@ -0,0 +1,18 @@
+-- =========================================================
+-- financeiro.transacoes: fingerprint pelo identificador do banco
+-- =========================================================
+-- O importador passou a identificar transações que trazem o
+-- identificador do banco (Identificador do Nubank, FITID do OFX)
+-- por sha256('<conta_id>|id:<identificador>'), e não mais por
+-- data/título/descrição/valor.
+--
+-- As transações do Nubank já gravadas guardam o identificador no
+-- início da descrição ("ID: <identificador> | ..."); o fingerprint
+-- delas é recalculado para que a reimportação continue idempotente.
+-- atualizado_em não é alterado: a transação não foi modificada pelo usuário.
+UPDATE financeiro.transacoes
+SET fingerprint = encode(
+      sha256(convert_to(conta_id::text || '|id:' || substring(descricao from '^ID: (\S+) \|'), 'UTF8')),
+      'hex'
+    )
+WHERE descricao ~ '^ID: \S+ \|';
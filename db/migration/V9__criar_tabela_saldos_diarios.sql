@@ -0,0 +1,35 @@
+-- =========================================================
+-- TABELA: financeiro.saldos_diarios
+-- =========================================================
+-- Saldo de fim de dia de cada conta, gravado pelo importador nos
+-- dias com transações ou com saldo informado pelo banco.
+--
+-- saldo_extrato é o saldo informado pelo banco (coluna Saldo do
+-- Inter, LEDGERBAL do OFX); nulo quando o extrato não traz saldo.
+-- saldo_calculado é contas.saldo_inicial mais os créditos e menos
+-- os débitos até a data, inclusive, e é recalculado a cada
+-- importação ou reversão que altera as transações da conta.
+CREATE TABLE financeiro.saldos_diarios (
+  conta_id          UUID NOT NULL REFERENCES financeiro.contas(id) ON DELETE RESTRICT,
+  data              DATE NOT NULL,
+
+  saldo_extrato     NUMERIC(14,2),
+  saldo_calculado   NUMERIC(14,2) NOT NULL,
+
+  -- O banco informou um saldo diferente do calculado: transações
+  -- faltando, saldo_inicial incorreto ou arquivo truncado
+  divergente        BOOLEAN GENERATED ALWAYS AS (
+                      saldo_extrato IS NOT NULL AND saldo_extrato <> saldo_calculado
+                    ) STORED,
+
+  -- Última importação que gravou o dia
+  importacao_id     UUID REFERENCES financeiro.importacoes(id) ON DELETE RESTRICT,
+
+  criado_em         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  atualizado_em     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT pk_saldos_diarios PRIMARY KEY (conta_id, data)
+);
+
+CREATE INDEX ix_saldos_diarios_data       ON financeiro.saldos_diarios (data);
+CREATE INDEX ix_saldos_diarios_importacao ON financeiro.saldos_diarios (importacao_id);
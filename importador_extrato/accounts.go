@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+// runAccounts lista as contas bancárias cadastradas e as empresas titulares
+func runAccounts(args []string) int {
+	fs := newFlagSet("accounts", "")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	_, database, err := connect()
+	if err != nil {
+		logger.Error("database unavailable", "error", err)
+		return exitConfig
+	}
+	defer database.Close()
+
+	contas, err := database.ListContas()
+	if err != nil {
+		logger.Error("error listing accounts", "error", err)
+		return exitFailure
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NÚMERO\tAGÊNCIA\tBANCO\tNOME\tEMPRESA\tCNPJ\tATIVA")
+	for _, c := range contas {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			valueOr(c.Numero, "-"),
+			valueOr(c.Agencia, "-"),
+			c.Banco,
+			c.Nome,
+			c.EmpresaNome,
+			c.EmpresaCNPJ,
+			yesNo(c.Ativo),
+		)
+	}
+	w.Flush()
+
+	return exitOK
+}
+
+func valueOr(s *string, fallback string) string {
+	if s == nil || *s == "" {
+		return fallback
+	}
+	return *s
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "sim"
+	}
+	return "não"
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/db"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+)
+
+// chartAction é uma ação do comando chart sobre o plano de contas de uma empresa
+type chartAction struct {
+	name        string
+	args        string
+	description string
+	run         func(database *db.DB, empresaID string, fs *flag.FlagSet) int
+	flags       func(fs *flag.FlagSet)
+}
+
+var chartActions = []chartAction{
+	{"list", "", "mostra a árvore do plano de contas", runChartList, nil},
+	{"add", "<código>", "cria uma conta abaixo da conta pai indicada pelo código (ex: 4.1.05 fica abaixo de 4.1)", runChartAdd, func(fs *flag.FlagSet) {
+		fs.String("name", "", "nome da conta (obrigatório)")
+		fs.String("nature", "", "natureza: receita, deducao, custo, despesa ou nao_operacional; padrão: a da conta pai")
+	}},
+	{"rename", "<código>", "altera o nome de uma conta", runChartRename, func(fs *flag.FlagSet) {
+		fs.String("name", "", "novo nome da conta (obrigatório)")
+	}},
+	{"deactivate", "<código>", "desativa uma conta: as regras deixam de usá-la", runChartSetAtiva(false), nil},
+	{"activate", "<código>", "reativa uma conta", runChartSetAtiva(true), nil},
+	{"remove", "<código>", "remove uma conta sem subcontas nem transações", runChartRemove, nil},
+	{"seed", "", "cria as contas do plano padrão (DRE de eventos no Simples Nacional) que a empresa ainda não tem", runChartSeed, nil},
+}
+
+// runChart gerencia o plano de contas das empresas: chart <ação> -company <cnpj> [opções]
+func runChart(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		chartUsage()
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	i := slices.IndexFunc(chartActions, func(a chartAction) bool { return a.name == args[0] })
+	if i < 0 {
+		fmt.Fprintf(os.Stderr, "Ação desconhecida: %s\n\n", args[0])
+		chartUsage()
+		return exitUsage
+	}
+	action := chartActions[i]
+
+	fs := newFlagSet("chart "+action.name, action.args)
+	company := fs.String("company", "", "CNPJ da empresa, sem máscara (obrigatório)")
+	if action.flags != nil {
+		action.flags(fs)
+	}
+	if code, ok := parseFlags(fs, args[1:]); !ok {
+		return code
+	}
+	if *company == "" || (action.args == "" && fs.NArg() != 0) || (action.args != "" && fs.NArg() != 1) {
+		fs.Usage()
+		return exitUsage
+	}
+
+	_, database, err := connect()
+	if err != nil {
+		logger.Error("database unavailable", "error", err)
+		return exitConfig
+	}
+	defer database.Close()
+
+	empresaID, err := database.GetEmpresaIDByCNPJ(*company)
+	if err != nil {
+		logger.Error("company not found", "cnpj", *company, "error", err)
+		return exitUsage
+	}
+
+	return action.run(database, empresaID, fs)
+}
+
+func chartUsage() {
+	fmt.Fprintf(os.Stderr, "Uso: %s chart <ação> -company <cnpj> [opções]\n\nAções:\n", os.Args[0])
+	for _, a := range chartActions {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", a.name, a.description)
+	}
+}
+
+func runChartList(database *db.DB, empresaID string, _ *flag.FlagSet) int {
+	contas, err := database.ListPlanoContas(empresaID)
+	if err != nil {
+		logger.Error("error listing chart of accounts", "error", err)
+		return exitFailure
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CÓDIGO\tNOME\tNATUREZA\tATIVA")
+	for _, c := range contas {
+		fmt.Fprintf(w, "%s\t%s%s\t%s\t%s\n",
+			c.Codigo,
+			strings.Repeat("  ", c.Nivel()-1),
+			c.Nome,
+			c.Natureza,
+			yesNo(c.Ativa),
+		)
+	}
+	w.Flush()
+
+	return exitOK
+}
+
+func runChartAdd(database *db.DB, empresaID string, fs *flag.FlagSet) int {
+	nome := flagValue(fs, "name")
+	if nome == "" {
+		fs.Usage()
+		return exitUsage
+	}
+
+	conta := &models.PlanoConta{EmpresaID: empresaID, Codigo: fs.Arg(0), Nome: nome, Natureza: flagValue(fs, "nature")}
+	if err := database.InsertPlanoConta(conta); err != nil {
+		logger.Error("error adding account", "codigo", conta.Codigo, "constraint", db.ConstraintName(err), "error", err)
+		return exitFailure
+	}
+
+	fmt.Printf("Conta %s - %s criada (%s)\n", conta.Codigo, conta.Nome, conta.Natureza)
+	return exitOK
+}
+
+func runChartRename(database *db.DB, empresaID string, fs *flag.FlagSet) int {
+	nome := flagValue(fs, "name")
+	if nome == "" {
+		fs.Usage()
+		return exitUsage
+	}
+
+	conta, code := findPlanoConta(database, empresaID, fs.Arg(0))
+	if conta == nil {
+		return code
+	}
+	if err := database.UpdatePlanoConta(empresaID, conta.Codigo, nome, conta.Ativa); err != nil {
+		logger.Error("error renaming account", "codigo", conta.Codigo, "error", err)
+		return exitFailure
+	}
+
+	fmt.Printf("Conta %s renomeada para %s\n", conta.Codigo, nome)
+	return exitOK
+}
+
+func runChartSetAtiva(ativa bool) func(*db.DB, string, *flag.FlagSet) int {
+	return func(database *db.DB, empresaID string, fs *flag.FlagSet) int {
+		conta, code := findPlanoConta(database, empresaID, fs.Arg(0))
+		if conta == nil {
+			return code
+		}
+		if err := database.UpdatePlanoConta(empresaID, conta.Codigo, conta.Nome, ativa); err != nil {
+			logger.Error("error updating account", "codigo", conta.Codigo, "error", err)
+			return exitFailure
+		}
+
+		fmt.Printf("Conta %s - %s ativa: %s\n", conta.Codigo, conta.Nome, yesNo(ativa))
+		return exitOK
+	}
+}
+
+func runChartRemove(database *db.DB, empresaID string, fs *flag.FlagSet) int {
+	codigo := fs.Arg(0)
+	if err := database.DeletePlanoConta(empresaID, codigo); err != nil {
+		if errors.Is(err, db.ErrForeignKey) {
+			logger.Error("account has sub-accounts or transactions - deactivate it instead", "codigo", codigo, "constraint", db.ConstraintName(err))
+			return exitFailure
+		}
+		logger.Error("error removing account", "codigo", codigo, "error", err)
+		return exitFailure
+	}
+
+	fmt.Printf("Conta %s removida\n", codigo)
+	return exitOK
+}
+
+func runChartSeed(database *db.DB, empresaID string, _ *flag.FlagSet) int {
+	inserted, err := database.SeedPlanoContas(empresaID)
+	if err != nil {
+		logger.Error("error seeding chart of accounts", "error", err)
+		return exitFailure
+	}
+
+	fmt.Printf("Contas criadas: %d\n", inserted)
+	return exitOK
+}
+
+// findPlanoConta busca a conta pelo código no plano da empresa; se não encontrar, retorna o código de saída
+func findPlanoConta(database *db.DB, empresaID, codigo string) (*models.PlanoConta, int) {
+	contas, err := database.ListPlanoContas(empresaID)
+	if err != nil {
+		logger.Error("error listing chart of accounts", "error", err)
+		return nil, exitFailure
+	}
+	for i := range contas {
+		if contas[i].Codigo == codigo {
+			return &contas[i], exitOK
+		}
+	}
+	logger.Error("account not found", "codigo", codigo)
+	return nil, exitUsage
+}
+
+// flagValue retorna o valor de uma flag registrada pela ação
+func flagValue(fs *flag.FlagSet, name string) string {
+	return fs.Lookup(name).Value.String()
+}
@@ -11,6 +11,10 @@ type Config struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
+
+	// ImportBestEffort grava as linhas válidas de um arquivo mesmo que outras falhem.
+	// Por padrão a importação de cada arquivo é atômica: qualquer falha desfaz o arquivo inteiro.
+	ImportBestEffort bool
 }
 
 func LoadConfig() (*Config, error) {
@@ -30,9 +34,17 @@ func LoadConfig() (*Config, error) {
 		DBUser:     user,
 		DBPassword: password,
 		DBName:     dbname,
+
+		ImportBestEffort: getEnvOrDefault("IMPORT_BEST_EFFORT", "false") == "true",
 	}, nil
 }
 
+// LoadLogConfig lê o nível (LOG_LEVEL: debug, info, warn, error) e o formato
+// (LOG_FORMAT: text, json) do log, que não dependem da configuração do banco de dados
+func LoadLogConfig() (level, format string) {
+	return getEnvOrDefault("LOG_LEVEL", "info"), getEnvOrDefault("LOG_FORMAT", "text")
+}
+
 func (c *Config) GetConnectionString() string {
 	return fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// InsertResult resume uma inserção em lote de transações
+type InsertResult struct {
+	Inserted   int
+	Duplicates int
+}
+
+// transacoesStagingColumns são as colunas copiadas para a tabela temporária, na ordem do COPY
+var transacoesStagingColumns = []string{
+	"id", "conta_id", "data", "titulo", "descricao",
+	"tipo_operacao", "tipo_transacao", "valor",
+	"criado_em", "atualizado_em", "fingerprint", "importacao_id",
+	"categoria", "subcategoria", "regra_id", "plano_conta_id", "contraparte_id",
+}
+
+// InsertTransactions grava um lote de transações com COPY em uma tabela temporária seguido de
+// INSERT ... ON CONFLICT DO NOTHING, ignorando as que já existem na conta (mesmo fingerprint).
+// Se qualquer linha for rejeitada pelo banco (ex: CHECK valor > 0) nenhuma é gravada.
+func (db *DB) InsertTransactions(tx *sqlx.Tx, transacoes []*models.Transaction) (*InsertResult, error) {
+	result := &InsertResult{}
+	if len(transacoes) == 0 {
+		return result, nil
+	}
+
+	// A tabela temporária não herda os CHECKs; eles são validados no INSERT final
+	_, err := tx.Exec(`
+CREATE TEMP TABLE IF NOT EXISTS transacoes_staging
+(LIKE financeiro.transacoes INCLUDING DEFAULTS)
+ON COMMIT DROP
+`)
+	if err != nil {
+		return nil, fmt.Errorf("error creating staging table: %v", err)
+	}
+
+	if _, err := tx.Exec(`TRUNCATE transacoes_staging`); err != nil {
+		return nil, fmt.Errorf("error truncating staging table: %v", err)
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn("transacoes_staging", transacoesStagingColumns...))
+	if err != nil {
+		return nil, fmt.Errorf("error preparing copy: %v", err)
+	}
+
+	for _, t := range transacoes {
+		_, err := stmt.Exec(
+			t.ID, t.ContaID, t.Data, t.Titulo, t.Descricao,
+			t.TipoOperacao, t.TipoTransacao, t.Valor,
+			t.CriadoEm, t.AtualizadoEm, t.Fingerprint, t.ImportacaoID,
+			t.Categoria, t.Subcategoria, t.RegraID, t.PlanoContaID, t.ContraparteID,
+		)
+		if err != nil {
+			stmt.Close()
+			return nil, fmt.Errorf("error copying transaction: %v", err)
+		}
+	}
+
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return nil, fmt.Errorf("error flushing copy: %v", err)
+	}
+	if err := stmt.Close(); err != nil {
+		return nil, fmt.Errorf("error closing copy: %v", err)
+	}
+
+	query := `
+INSERT INTO financeiro.transacoes (
+id, conta_id, data, titulo, descricao,
+tipo_operacao, tipo_transacao, valor,
+criado_em, atualizado_em, fingerprint, importacao_id,
+categoria, subcategoria, regra_id, plano_conta_id, contraparte_id
+)
+SELECT
+id, conta_id, data, titulo, descricao,
+tipo_operacao, tipo_transacao, valor,
+criado_em, atualizado_em, fingerprint, importacao_id,
+categoria, subcategoria, regra_id, plano_conta_id, contraparte_id
+FROM transacoes_staging
+ORDER BY id
+ON CONFLICT (conta_id, fingerprint) DO NOTHING
+RETURNING id
+`
+
+	var insertedIDs []string
+	if err := tx.Select(&insertedIDs, query); err != nil {
+		return nil, fmt.Errorf("error inserting transactions: %w", classifyError(err))
+	}
+
+	result.Inserted = len(insertedIDs)
+	result.Duplicates = len(transacoes) - result.Inserted
+
+	db.logger.Debug("transactions inserted",
+		"conta_id", transacoes[0].ContaID,
+		"inserted", result.Inserted,
+		"duplicates", result.Duplicates)
+
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// testDB conecta ao banco de IMPORTADOR_TEST_DATABASE_URL, já com as migrações aplicadas.
+// Sem a variável o teste é ignorado.
+func testDB(t *testing.T) *DB {
+	t.Helper()
+	url := os.Getenv("IMPORTADOR_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("IMPORTADOR_TEST_DATABASE_URL not set")
+	}
+	db, err := NewConnection(url, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestInsertTransactions(t *testing.T) {
+	db := testDB(t)
+
+	// Tudo é feito em uma transação desfeita no fim do teste
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	empresaID, contaID := uuid.NewString(), uuid.NewString()
+	cnpj := fmt.Sprintf("%014d", time.Now().UnixNano()%1e14)
+	if _, err := tx.Exec(`INSERT INTO cadastros.empresas (id, nome, cnpj) VALUES ($1, 'Empresa Teste', $2)`, empresaID, cnpj); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(`INSERT INTO financeiro.contas (id, empresa_id, banco, numero, nome) VALUES ($1, $2, 'Banco Teste', '000000001', 'Conta Teste')`, contaID, empresaID); err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2025, time.January, 10, 0, 0, 0, 0, time.UTC)
+	pix := TransactionInput{Date: day, Description: "Pix enviado", Details: "Fulano", Amount: -5000}
+	inputs := []TransactionInput{pix, pix, {BankID: "FIT1", Date: day, Description: "Pix recebido", Amount: 10000}}
+
+	// Repetições legítimas no arquivo são todas gravadas
+	result, err := db.InsertTransactions(tx, NewTransactions("", contaID, inputs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *result != (InsertResult{Inserted: 3}) {
+		t.Errorf("first insert = %+v, want 3 inserted", *result)
+	}
+
+	// Reimportar o mesmo arquivo não grava nada
+	result, err = db.InsertTransactions(tx, NewTransactions("", contaID, inputs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *result != (InsertResult{Duplicates: 3}) {
+		t.Errorf("second insert = %+v, want 3 duplicates", *result)
+	}
+
+	// Uma linha rejeitada pelo CHECK impede a gravação do lote inteiro
+	if _, err := tx.Exec(`SAVEPOINT lote`); err != nil {
+		t.Fatal(err)
+	}
+	invalid := NewTransactions("", contaID, []TransactionInput{
+		{Date: day.AddDate(0, 0, 1), Description: "Pix enviado", Details: "Ciclano", Amount: -100},
+		{Date: day.AddDate(0, 0, 1), Description: "Tarifa", Amount: 0},
+	})
+	if _, err := db.InsertTransactions(tx, invalid); !errors.Is(err, ErrCheckViolation) {
+		t.Errorf("insert with valor 0 error = %v, want %v", err, ErrCheckViolation)
+	}
+	if _, err := tx.Exec(`ROLLBACK TO SAVEPOINT lote`); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	if err := tx.Get(&count, `SELECT COUNT(*) FROM financeiro.transacoes WHERE conta_id = $1`, contaID); err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("got %d transactions in the conta, want 3", count)
+	}
+}
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ContraparteInput é a contraparte de uma transação, como extraída do extrato
+type ContraparteInput struct {
+	Nome        string
+	Documento   string // sem máscara; dígitos ocultos como '*'
+	BancoNome   string
+	BancoCodigo string
+	ISPB        string
+	Agencia     string
+	Conta       string
+}
+
+// NewContraparte monta o registro da contraparte. A chave é o CPF/CNPJ quando completo; sem ele, o nome
+// em maiúsculas e sem espaços repetidos, junto com o CPF mascarado, se houver, para que homônimos
+// com CPFs diferentes não se confundam.
+func NewContraparte(in ContraparteInput) *models.Contraparte {
+	nome := strings.Join(strings.Fields(in.Nome), " ")
+
+	chave := "nome:" + strings.ToUpper(nome)
+	switch {
+	case (len(in.Documento) == 11 || len(in.Documento) == 14) && !strings.Contains(in.Documento, "*"):
+		chave = "doc:" + in.Documento
+	case in.Documento != "":
+		chave += "|" + in.Documento
+	}
+
+	now := time.Now()
+	return &models.Contraparte{
+		ID:           uuid.Must(uuid.NewV7()).String(),
+		Chave:        chave,
+		Nome:         nome,
+		Documento:    optional(in.Documento),
+		BancoNome:    optional(in.BancoNome),
+		BancoCodigo:  optional(in.BancoCodigo),
+		ISPB:         optional(in.ISPB),
+		Agencia:      optional(in.Agencia),
+		Conta:        optional(in.Conta),
+		CriadoEm:     now,
+		AtualizadoEm: now,
+	}
+}
+
+// optional retorna nil para a string vazia
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// UpsertContrapartes grava as contrapartes ainda não cadastradas e atualiza os dados bancários das
+// existentes (mesma chave). O ID de cada contraparte passa a ser o do registro gravado.
+func (db *DB) UpsertContrapartes(tx *sqlx.Tx, contrapartes []*models.Contraparte) error {
+	unique := uniqueContrapartes(contrapartes)
+	if len(unique) == 0 {
+		return nil
+	}
+
+	n := len(unique)
+	ids, chaves, nomes := make([]string, n), make([]string, n), make([]string, n)
+	documentos, bancoNomes, bancoCodigos := make([]sql.NullString, n), make([]sql.NullString, n), make([]sql.NullString, n)
+	ispbs, agencias, contas := make([]sql.NullString, n), make([]sql.NullString, n), make([]sql.NullString, n)
+	for i, c := range unique {
+		ids[i], chaves[i], nomes[i] = c.ID, c.Chave, c.Nome
+		documentos[i], bancoNomes[i], bancoCodigos[i] = nullString(c.Documento), nullString(c.BancoNome), nullString(c.BancoCodigo)
+		ispbs[i], agencias[i], contas[i] = nullString(c.ISPB), nullString(c.Agencia), nullString(c.Conta)
+	}
+
+	// O DO UPDATE, mesmo sem mudar nada, faz o RETURNING trazer também as contrapartes já cadastradas
+	query := `
+INSERT INTO financeiro.contrapartes (
+id, chave, nome, documento, banco_nome, banco_codigo, ispb, agencia, conta, criado_em, atualizado_em
+)
+SELECT u.id, u.chave, u.nome, u.documento, u.banco_nome, u.banco_codigo, u.ispb, u.agencia, u.conta, NOW(), NOW()
+FROM UNNEST($1::uuid[], $2::text[], $3::text[], $4::text[], $5::text[], $6::text[], $7::text[], $8::text[], $9::text[])
+  AS u(id, chave, nome, documento, banco_nome, banco_codigo, ispb, agencia, conta)
+ON CONFLICT (chave) DO UPDATE SET
+  banco_nome   = COALESCE(EXCLUDED.banco_nome, contrapartes.banco_nome),
+  banco_codigo = COALESCE(EXCLUDED.banco_codigo, contrapartes.banco_codigo),
+  ispb         = COALESCE(EXCLUDED.ispb, contrapartes.ispb),
+  agencia      = COALESCE(EXCLUDED.agencia, contrapartes.agencia),
+  conta        = COALESCE(EXCLUDED.conta, contrapartes.conta)
+RETURNING chave, id
+`
+	rows, err := tx.Queryx(query,
+		pq.Array(ids), pq.Array(chaves), pq.Array(nomes), pq.Array(documentos), pq.Array(bancoNomes),
+		pq.Array(bancoCodigos), pq.Array(ispbs), pq.Array(agencias), pq.Array(contas))
+	if err != nil {
+		return fmt.Errorf("error upserting contrapartes: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	saved := make(map[string]string, n)
+	for rows.Next() {
+		var chave, id string
+		if err := rows.Scan(&chave, &id); err != nil {
+			return fmt.Errorf("error reading contrapartes: %v", err)
+		}
+		saved[chave] = id
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error upserting contrapartes: %w", classifyError(err))
+	}
+
+	for _, c := range contrapartes {
+		if c != nil {
+			c.ID = saved[c.Chave]
+		}
+	}
+
+	db.logger.Debug("contrapartes upserted", "contrapartes", n)
+
+	return nil
+}
+
+// uniqueContrapartes retorna a primeira contraparte de cada chave, ignorando as nil.
+// O mesmo INSERT ... ON CONFLICT DO UPDATE não pode alterar a mesma linha duas vezes.
+func uniqueContrapartes(contrapartes []*models.Contraparte) []*models.Contraparte {
+	seen := map[string]bool{}
+	var unique []*models.Contraparte
+	for _, c := range contrapartes {
+		if c == nil || seen[c.Chave] {
+			continue
+		}
+		seen[c.Chave] = true
+		unique = append(unique, c)
+	}
+	return unique
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/lib/pq"
+)
+
+// ListDasDocumentos retorna os documentos DAS de todas as empresas, em ordem de vencimento
+func (db *DB) ListDasDocumentos() ([]models.DasDocumento, error) {
+	var documentos []models.DasDocumento
+	err := db.Select(&documentos, `SELECT `+dasColumns+` FROM financeiro.das_documentos ORDER BY data_vencimento, numero_documento`)
+	if err != nil {
+		return nil, fmt.Errorf("error listing das documentos: %v", err)
+	}
+	return documentos, nil
+}
+
+// DebitoEmpresa é um débito bancário com a empresa titular da conta
+type DebitoEmpresa struct {
+	models.Transaction
+	EmpresaID string `db:"empresa_id"`
+	ContaNome string `db:"conta_nome"`
+}
+
+// ListDebitosSemDas retorna os débitos no intervalo [from, to] que ainda não pagaram um documento DAS,
+// fora as transferências internas, em ordem de data
+func (db *DB) ListDebitosSemDas(from, to time.Time) ([]DebitoEmpresa, error) {
+	query := `
+SELECT t.*, c.empresa_id, c.nome AS conta_nome
+FROM (SELECT ` + transacaoColumns + ` FROM financeiro.transacoes) t
+JOIN financeiro.contas c ON c.id = t.conta_id
+WHERE t.tipo_operacao = 'debito'
+  AND t.data BETWEEN $1 AND $2
+  AND NOT EXISTS (SELECT 1 FROM financeiro.das_documentos d WHERE d.transacao_id = t.id)
+  AND NOT EXISTS (SELECT 1 FROM financeiro.transferencias_internas ti WHERE ti.debito_id = t.id)
+ORDER BY t.data, t.id
+`
+	var debitos []DebitoEmpresa
+	if err := db.Select(&debitos, query, from, to); err != nil {
+		return nil, fmt.Errorf("error listing debitos: %v", err)
+	}
+	return debitos, nil
+}
+
+// DasPagamento liga um documento DAS ao débito que o pagou
+type DasPagamento struct {
+	DocumentoID string
+	TransacaoID string
+}
+
+// ConciliarDas marca, em uma única transação, os documentos pagos, com o débito correspondente, e os
+// documentos vencidos. Só documentos EMITIDOS ou VENCIDOS são alterados, e os EMITIDOS só passam a
+// VENCIDO. Retorna o número de documentos pagos e vencidos alterados.
+func (db *DB) ConciliarDas(pagamentos []DasPagamento, vencidos []string) (int, int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, 0, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	documentos := make([]string, len(pagamentos))
+	transacoes := make([]string, len(pagamentos))
+	for i, p := range pagamentos {
+		documentos[i], transacoes[i] = p.DocumentoID, p.TransacaoID
+	}
+
+	now := time.Now()
+	result, err := tx.Exec(`
+UPDATE financeiro.das_documentos d
+SET status = $3, transacao_id = u.transacao_id, atualizado_em = $5
+FROM UNNEST($1::uuid[], $2::uuid[]) AS u(id, transacao_id)
+WHERE d.id = u.id AND d.status IN ($4, $6)
+`, pq.Array(documentos), pq.Array(transacoes), models.DasPago, models.DasEmitido, now, models.DasVencido)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error updating paid das documentos: %w", classifyError(err))
+	}
+	pagos, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, fmt.Errorf("error updating paid das documentos: %v", err)
+	}
+
+	result, err = tx.Exec(`
+UPDATE financeiro.das_documentos
+SET status = $2, atualizado_em = $4
+WHERE id = ANY($1::uuid[]) AND status = $3
+`, pq.Array(vencidos), models.DasVencido, models.DasEmitido, now)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error updating overdue das documentos: %v", err)
+	}
+	vencidosAtualizados, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, fmt.Errorf("error updating overdue das documentos: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("error committing das reconciliation: %v", err)
+	}
+
+	db.logger.Info("das documentos reconciled", "pagos", pagos, "vencidos", vencidosAtualizados)
+
+	return int(pagos), int(vencidosAtualizados), nil
+}
@@ -3,16 +3,30 @@ package db
 import (
 	"crypto/sha256"
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 )
 
+// normalizeContaNumero mantém só os dígitos do número da conta
+func normalizeContaNumero(numero string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, numero)
+}
+
 type DB struct {
 	*sqlx.DB
+	logger *slog.Logger
 }
 
 func (db *DB) GetEmpresaIDByCNPJ(CNPJ string) (string, error) {
@@ -25,114 +39,194 @@ func (db *DB) GetEmpresaIDByCNPJ(CNPJ string) (string, error) {
 	return id, nil
 }
 
+// GetContaIDByNumero encontra a conta ativa pelo número (veja findContaID)
 func (db *DB) GetContaIDByNumero(numero string) (string, error) {
-	query := `SELECT id FROM financeiro.contas WHERE numero = $1 AND ativo = true`
-	var id string
-	err := db.Get(&id, query, numero)
+	id, err := db.findContaID(numero, true)
 	if err != nil {
 		return "", fmt.Errorf("error finding active conta by numero: %v", err)
 	}
 	return id, nil
 }
 
-func NewConnection(connectionString string) (*DB, error) {
+// contaNumeroDigitos é o número da conta só com os dígitos, como no índice ix_contas_numero_digitos
+const contaNumeroDigitos = `regexp_replace(numero, '\D', '', 'g')`
+
+// findContaID encontra a conta pelo número, comparando só os dígitos: o cadastro e os extratos nem
+// sempre usam os mesmos separadores ("12345678-9" e "123456789"). É a busca usada pela importação
+// e pelos filtros -account dos comandos. Mais de uma conta com os mesmos dígitos é um erro.
+func (db *DB) findContaID(numero string, soAtivas bool) (string, error) {
+	digitos := normalizeContaNumero(numero)
+	if digitos == "" {
+		return "", fmt.Errorf("no digits in numero %q", numero)
+	}
+
+	query := `SELECT id FROM financeiro.contas WHERE ` + contaNumeroDigitos + ` = $1 AND (ativo OR NOT $2) ORDER BY id LIMIT 2`
+	var ids []string
+	if err := db.Select(&ids, query, digitos, soAtivas); err != nil {
+		return "", err
+	}
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("no conta with numero %s", numero)
+	case 1:
+		return ids[0], nil
+	}
+	return "", fmt.Errorf("more than one conta with numero %s", numero)
+}
+
+// ContaEmpresa é uma conta bancária com o nome e o CNPJ da empresa titular
+type ContaEmpresa struct {
+	models.Conta
+	EmpresaNome string `db:"empresa_nome"`
+	EmpresaCNPJ string `db:"empresa_cnpj"`
+}
+
+func (db *DB) ListContas() ([]ContaEmpresa, error) {
+	query := `
+SELECT c.id, c.empresa_id, c.banco, c.agencia, c.numero, c.nome,
+c.saldo_inicial, c.ativo, c.criado_em, c.atualizado_em,
+e.nome AS empresa_nome, e.cnpj AS empresa_cnpj
+FROM financeiro.contas c
+JOIN cadastros.empresas e ON e.id = c.empresa_id
+ORDER BY e.nome, c.banco, c.numero
+`
+	var contas []ContaEmpresa
+	err := db.Select(&contas, query)
+	if err != nil {
+		return nil, fmt.Errorf("error listing contas: %v", err)
+	}
+	return contas, nil
+}
+
+// NewConnection conecta ao banco de dados. Com logger nil usa o logger padrão do slog.
+func NewConnection(connectionString string, logger *slog.Logger) (*DB, error) {
 	db, err := sqlx.Connect("postgres", connectionString)
 	if err != nil {
 		return nil, fmt.Errorf("error connecting to database: %v", err)
 	}
 
-	return &DB{db}, nil
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &DB{DB: db, logger: logger}, nil
 }
 
-func (db *DB) InsertTransaction(contaID string, date time.Time, description, details string, amount float64) error {
+// TransactionInput são os dados de uma transação do extrato usados para montar o registro
+type TransactionInput struct {
+	BankID      string // identificador da transação no banco (FITID, Identificador do Nubank), se houver
+	Date        time.Time
+	Description string
+	Details     string
+	Amount      money.Centavos
+}
+
+// NewTransactions monta os registros das transações de um arquivo, com fingerprint e tipo de operação.
+// Transações sem identificador do banco e idênticas dentro do arquivo recebem o índice da
+// ocorrência no fingerprint, para que repetições legítimas não sejam descartadas como duplicadas.
+func NewTransactions(importacaoID, contaID string, inputs []TransactionInput) []*models.Transaction {
+	occurrences := map[string]int{}
+
+	transacoes := make([]*models.Transaction, len(inputs))
+	for i, in := range inputs {
+		var fingerprint string
+		if in.BankID != "" {
+			fingerprint = generateBankIDFingerprint(contaID, in.BankID)
+		} else {
+			key := generateFingerprint(contaID, in.Date, in.Description, in.Details, in.Amount, 0)
+			fingerprint = generateFingerprint(contaID, in.Date, in.Description, in.Details, in.Amount, occurrences[key])
+			occurrences[key]++
+		}
+
+		transacoes[i] = newTransaction(importacaoID, contaID, in, fingerprint)
+	}
+
+	return transacoes
+}
+
+func newTransaction(importacaoID, contaID string, in TransactionInput, fingerprint string) *models.Transaction {
 	// Generate UUID v7 for the transaction
 	id := uuid.Must(uuid.NewV7())
 
-	// Generate fingerprint to avoid duplicates
-	fingerprint := generateFingerprint(contaID, date, description, details, amount)
-
 	// Determine operation type based on amount
 	tipoOperacao := "debito"
-	if amount > 0 {
+	if in.Amount > 0 {
 		tipoOperacao = "credito"
 	}
 
-	// Use absolute value for the amount as per database schema
-	if amount < 0 {
-		amount = -amount
-	}
-
 	now := time.Now()
 
-	// Create transaction record
-	tx := &models.Transaction{
+	return &models.Transaction{
 		ID:            id.String(),
 		ContaID:       contaID,
-		Data:          date,
-		Titulo:        description,
-		Descricao:     details,
+		Data:          in.Date,
+		Titulo:        in.Description,
+		Descricao:     in.Details,
 		TipoOperacao:  tipoOperacao,
-		TipoTransacao: getTipoTransacao(description),
-		Valor:         amount,
+		TipoTransacao: getTipoTransacao(in.Description),
+		Valor:         in.Amount.Abs(), // Use absolute value for the amount as per database schema
 		CriadoEm:      now,
 		AtualizadoEm:  now,
 		Fingerprint:   fingerprint,
+		ImportacaoID:  &importacaoID,
 	}
+}
 
-	// Check if transaction already exists
-	existsQuery := `
-SELECT EXISTS(
-  SELECT 1 FROM financeiro.transacoes 
-  WHERE conta_id = $1 
-  AND data = $2 
-  AND titulo = $3 
-  AND descricao = $4 
-  AND valor = $5
-)
-`
-	var exists bool
-	existsErr := db.QueryRow(existsQuery, contaID, date, description, details, amount).Scan(&exists)
-	if existsErr != nil {
-		return fmt.Errorf("error checking for existing transaction: %v", existsErr)
-	}
-
-	if exists {
-		return nil // Skip duplicate transaction
-	}
-
-	// Insert into database
+// InsertTransaction grava uma transação, ignorando-a se a conta já tiver outra com o mesmo fingerprint.
+// Retorna false quando a transação já existia.
+func (db *DB) InsertTransaction(tx *sqlx.Tx, transacao *models.Transaction) (bool, error) {
 	query := `
 INSERT INTO financeiro.transacoes (
 id, conta_id, data, titulo, descricao,
 tipo_operacao, tipo_transacao, valor,
-criado_em, atualizado_em, fingerprint
+criado_em, atualizado_em, fingerprint, importacao_id,
+categoria, subcategoria, regra_id, plano_conta_id, contraparte_id
 ) VALUES (
 :id, :conta_id, :data, :titulo, :descricao,
 :tipo_operacao, :tipo_transacao, :valor,
-:criado_em, :atualizado_em, :fingerprint
+:criado_em, :atualizado_em, :fingerprint, :importacao_id,
+:categoria, :subcategoria, :regra_id, :plano_conta_id, :contraparte_id
 )
+ON CONFLICT (conta_id, fingerprint) DO NOTHING
 `
 
-	_, err := db.NamedExec(query, tx)
+	result, err := tx.NamedExec(query, transacao)
 	if err != nil {
-		return fmt.Errorf("error inserting transaction: %v", err)
+		return false, fmt.Errorf("error inserting transaction: %w", classifyError(err))
 	}
 
-	return nil
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error inserting transaction: %v", err)
+	}
+
+	return inserted == 1, nil
 }
 
-func generateFingerprint(contaID string, date time.Time, description, details string, amount float64) string {
-	data := fmt.Sprintf("%s|%s|%s|%s|%.2f",
+// generateFingerprint identifica a transação pelos seus dados. A primeira ocorrência mantém o
+// formato original, compatível com as transações já gravadas; as demais recebem o índice.
+func generateFingerprint(contaID string, date time.Time, description, details string, amount money.Centavos, occurrence int) string {
+	data := fmt.Sprintf("%s|%s|%s|%s|%s",
 		contaID,
 		date.Format("2006-01-02"),
 		description,
 		details,
 		amount,
 	)
+	if occurrence > 0 {
+		data = fmt.Sprintf("%s|%d", data, occurrence)
+	}
 	hash := sha256.Sum256([]byte(data))
 	return fmt.Sprintf("%x", hash)
 }
 
+// generateBankIDFingerprint identifica a transação pelo identificador atribuído pelo banco.
+// Deve ser mantido igual ao cálculo da migração V8__fingerprint_id_banco.sql.
+func generateBankIDFingerprint(contaID, bankID string) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s|id:%s", contaID, bankID)))
+	return fmt.Sprintf("%x", hash)
+}
+
 func getTipoTransacao(description string) string {
 	// Map common transaction descriptions to types
 	switch {
@@ -151,23 +245,10 @@ func contains(s, substr string) bool {
 	return len(s) >= len(substr) && s[:len(substr)] == substr
 }
 
-func (db *DB) InsertDasDocumento(empresaID string, periodoApuracao time.Time, dataVencimento time.Time, numeroDocumento string, valorTotal float64) error {
-	// Generate UUID v7 for the transaction
-	id := uuid.Must(uuid.NewV7())
-
-	now := time.Now()
-
-	// Create transaction record
-	tx := &models.DasDocumento{
-		ID:              id.String(),
-		EmpresaID:       empresaID,
-		PeriodoApuracao: periodoApuracao,
-		DataVencimento:  dataVencimento,
-		NumeroDocumento: numeroDocumento,
-		ValorTotal:      valorTotal,
-		CriadoEm:        now,
-		AtualizadoEm:    now,
-	}
+// InsertDasDocumento grava o documento DAS, ignorando-o se a empresa já tiver o mesmo número de documento.
+// Retorna false quando o documento já existia.
+func (db *DB) InsertDasDocumento(tx *sqlx.Tx, importacaoID, empresaID string, periodoApuracao time.Time, dataVencimento time.Time, numeroDocumento string, valorTotal money.Centavos) (bool, error) {
+	documento := newDasDocumento(importacaoID, empresaID, periodoApuracao, dataVencimento, numeroDocumento, valorTotal)
 
 	// Check if transaction already exists
 	existsQuery := `
@@ -178,29 +259,142 @@ SELECT EXISTS(
 )
 `
 	var exists bool
-	existsErr := db.QueryRow(existsQuery, empresaID, numeroDocumento).Scan(&exists)
+	existsErr := tx.QueryRow(existsQuery, empresaID, numeroDocumento).Scan(&exists)
 	if existsErr != nil {
-		return fmt.Errorf("error checking for existing das documento: %v", existsErr)
+		return false, fmt.Errorf("error checking for existing das documento: %v", existsErr)
 	}
 
 	if exists {
-		return nil // Skip duplicate transaction
+		return false, nil // Skip duplicate transaction
 	}
 
 	// Insert into database
 	query := `
 INSERT INTO financeiro.das_documentos (
 id, empresa_id, periodo_apuracao, data_vencimento, numero_documento, valor_total,
-criado_em, atualizado_em
+criado_em, atualizado_em, importacao_id
 ) VALUES (
 :id, :empresa_id, :periodo_apuracao, :data_vencimento, :numero_documento, :valor_total,
-:criado_em, :atualizado_em
+:criado_em, :atualizado_em, :importacao_id
+)
+`
+
+	_, err := tx.NamedExec(query, documento)
+	if err != nil {
+		return false, fmt.Errorf("error inserting das documento: %w", classifyError(err))
+	}
+
+	return true, nil
+}
+
+func newDasDocumento(importacaoID, empresaID string, periodoApuracao time.Time, dataVencimento time.Time, numeroDocumento string, valorTotal money.Centavos) *models.DasDocumento {
+	// Generate UUID v7 for the das documento
+	id := uuid.Must(uuid.NewV7())
+
+	now := time.Now()
+
+	return &models.DasDocumento{
+		ID:              id.String(),
+		EmpresaID:       empresaID,
+		PeriodoApuracao: periodoApuracao,
+		DataVencimento:  dataVencimento,
+		NumeroDocumento: numeroDocumento,
+		ValorTotal:      valorTotal,
+		Status:          models.DasEmitido,
+		CriadoEm:        now,
+		AtualizadoEm:    now,
+		ImportacaoID:    &importacaoID,
+	}
+}
+
+func newImportacao(arquivoNome, arquivoSHA256 string) *models.Importacao {
+	now := time.Now()
+
+	return &models.Importacao{
+		ID:            uuid.Must(uuid.NewV7()).String(),
+		ArquivoNome:   arquivoNome,
+		ArquivoSHA256: arquivoSHA256,
+		Status:        models.ImportacaoEmAndamento,
+		IniciadoEm:    now,
+		CriadoEm:      now,
+		AtualizadoEm:  now,
+	}
+}
+
+func (db *DB) StartImportacao(arquivoNome, arquivoSHA256 string) (*models.Importacao, error) {
+	imp := newImportacao(arquivoNome, arquivoSHA256)
+
+	query := `
+INSERT INTO financeiro.importacoes (
+id, arquivo_nome, arquivo_sha256, status,
+iniciado_em, criado_em, atualizado_em
+) VALUES (
+:id, :arquivo_nome, :arquivo_sha256, :status,
+:iniciado_em, :criado_em, :atualizado_em
 )
 `
 
-	_, err := db.NamedExec(query, tx)
+	_, err := db.NamedExec(query, imp)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting importacao: %v", err)
+	}
+
+	db.logger.Debug("importacao started", "importacao_id", imp.ID, "file", arquivoNome)
+
+	return imp, nil
+}
+
+func (db *DB) FinishImportacao(imp *models.Importacao) error {
+	now := time.Now()
+	imp.FinalizadoEm = &now
+	imp.AtualizadoEm = now
+
+	query := `
+UPDATE financeiro.importacoes SET
+  parser = :parser,
+  conta_id = :conta_id,
+  empresa_id = :empresa_id,
+  status = :status,
+  mensagem_erro = :mensagem_erro,
+  qtd_importadas = :qtd_importadas,
+  qtd_ignoradas = :qtd_ignoradas,
+  qtd_falhas = :qtd_falhas,
+  finalizado_em = :finalizado_em,
+  atualizado_em = :atualizado_em
+WHERE id = :id
+`
+
+	_, err := db.NamedExec(query, imp)
 	if err != nil {
-		return fmt.Errorf("error inserting transaction: %v", err)
+		return fmt.Errorf("error updating importacao: %v", err)
+	}
+
+	db.logger.Debug("importacao finished",
+		"importacao_id", imp.ID,
+		"status", imp.Status,
+		"imported", imp.QtdImportadas,
+		"skipped", imp.QtdIgnoradas,
+		"failed", imp.QtdFalhas)
+
+	return nil
+}
+
+// WithSavepoint executa fn dentro de um savepoint, desfazendo apenas o que fn gravou em caso de erro.
+// Assim um registro rejeitado pelo banco não aborta a transação do arquivo inteiro.
+func WithSavepoint(tx *sqlx.Tx, fn func() error) error {
+	if _, err := tx.Exec(`SAVEPOINT importacao_registro`); err != nil {
+		return fmt.Errorf("error creating savepoint: %v", err)
+	}
+
+	if fnErr := fn(); fnErr != nil {
+		if _, err := tx.Exec(`ROLLBACK TO SAVEPOINT importacao_registro`); err != nil {
+			return fmt.Errorf("error rolling back to savepoint: %v (after %v)", err, fnErr)
+		}
+		return fnErr
+	}
+
+	if _, err := tx.Exec(`RELEASE SAVEPOINT importacao_registro`); err != nil {
+		return fmt.Errorf("error releasing savepoint: %v", err)
 	}
 
 	return nil
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewTransactionsFingerprint(t *testing.T) {
+	day := time.Date(2025, time.January, 10, 0, 0, 0, 0, time.UTC)
+	pix := TransactionInput{Date: day, Description: "Pix enviado", Details: "Fulano", Amount: -5000}
+
+	tests := []struct {
+		name   string
+		inputs []TransactionInput
+		want   []string // fingerprint esperado de cada transação
+	}{
+		{
+			name:   "primeira ocorrência mantém o formato original",
+			inputs: []TransactionInput{pix},
+			want:   []string{generateFingerprint("conta", day, "Pix enviado", "Fulano", -5000, 0)},
+		},
+		{
+			name:   "repetições no arquivo recebem o índice da ocorrência",
+			inputs: []TransactionInput{pix, pix, pix},
+			want: []string{
+				generateFingerprint("conta", day, "Pix enviado", "Fulano", -5000, 0),
+				generateFingerprint("conta", day, "Pix enviado", "Fulano", -5000, 1),
+				generateFingerprint("conta", day, "Pix enviado", "Fulano", -5000, 2),
+			},
+		},
+		{
+			name: "identificador do banco tem precedência",
+			inputs: []TransactionInput{
+				{BankID: "FIT1", Date: day, Description: "Pix enviado", Details: "Fulano", Amount: -5000},
+				{BankID: "FIT2", Date: day, Description: "Pix enviado", Details: "Fulano", Amount: -5000},
+				pix,
+			},
+			want: []string{
+				generateBankIDFingerprint("conta", "FIT1"),
+				generateBankIDFingerprint("conta", "FIT2"),
+				// As transações com identificador não contam como ocorrências
+				generateFingerprint("conta", day, "Pix enviado", "Fulano", -5000, 0),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewTransactions("importacao", "conta", tt.inputs)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d transactions, want %d", len(got), len(tt.want))
+			}
+			seen := map[string]bool{}
+			for i, tr := range got {
+				if tr.Fingerprint != tt.want[i] {
+					t.Errorf("transaction %d fingerprint = %s, want %s", i, tr.Fingerprint, tt.want[i])
+				}
+				if seen[tr.Fingerprint] {
+					t.Errorf("transaction %d repeats fingerprint %s", i, tr.Fingerprint)
+				}
+				seen[tr.Fingerprint] = true
+			}
+
+			// Reimportar o mesmo arquivo gera os mesmos fingerprints, que o ON CONFLICT descarta
+			again := NewTransactions("outra-importacao", "conta", tt.inputs)
+			for i := range again {
+				if again[i].Fingerprint != got[i].Fingerprint {
+					t.Errorf("re-import transaction %d fingerprint = %s, want %s", i, again[i].Fingerprint, got[i].Fingerprint)
+				}
+			}
+		})
+	}
+}
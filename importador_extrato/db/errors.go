@@ -0,0 +1,69 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Tipos de violação de restrição do banco, para uso com errors.Is
+var (
+	ErrDuplicate      = errors.New("duplicate key")              // unique ou primary key (SQLSTATE 23505)
+	ErrForeignKey     = errors.New("foreign key violation")      // SQLSTATE 23503
+	ErrCheckViolation = errors.New("check constraint violation") // ex: valor > 0, ck_das_periodo_dia1 (SQLSTATE 23514)
+)
+
+// Códigos SQLSTATE das violações de integridade do PostgreSQL
+const (
+	sqlStateUniqueViolation     = "23505"
+	sqlStateForeignKeyViolation = "23503"
+	sqlStateCheckViolation      = "23514"
+)
+
+// ConstraintError é um registro rejeitado por uma restrição do banco.
+// errors.Is(err, ErrDuplicate) (ou ErrForeignKey, ErrCheckViolation) identifica o tipo.
+type ConstraintError struct {
+	Kind       error  // ErrDuplicate, ErrForeignKey ou ErrCheckViolation
+	Constraint string // nome da restrição, ex: uq_das_empresa_periodo
+	Err        error  // erro original do driver
+}
+
+func (e *ConstraintError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConstraintError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// ConstraintName retorna o nome da restrição violada, ou "" se err não for uma violação de restrição
+func ConstraintName(err error) string {
+	var ce *ConstraintError
+	if errors.As(err, &ce) {
+		return ce.Constraint
+	}
+	return ""
+}
+
+// classifyError converte as violações de restrição do PostgreSQL em ConstraintError.
+// Outros erros são retornados sem alteração.
+func classifyError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	var kind error
+	switch pqErr.Code {
+	case sqlStateUniqueViolation:
+		kind = ErrDuplicate
+	case sqlStateForeignKeyViolation:
+		kind = ErrForeignKey
+	case sqlStateCheckViolation:
+		kind = ErrCheckViolation
+	default:
+		return err
+	}
+
+	return &ConstraintError{Kind: kind, Constraint: pqErr.Constraint, Err: err}
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		kind       error
+		constraint string
+	}{
+		{"unique", &pq.Error{Code: "23505", Constraint: "uq_das_empresa_periodo"}, ErrDuplicate, "uq_das_empresa_periodo"},
+		{"foreign key", &pq.Error{Code: "23503", Constraint: "transacoes_conta_id_fkey"}, ErrForeignKey, "transacoes_conta_id_fkey"},
+		{"check", &pq.Error{Code: "23514", Constraint: "ck_das_periodo_dia1"}, ErrCheckViolation, "ck_das_periodo_dia1"},
+		{"other sqlstate", &pq.Error{Code: "22001"}, nil, ""},
+		{"not a pq error", errors.New("connection refused"), nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Como nos métodos do DB, o erro chega embrulhado na mensagem da operação
+			err := fmt.Errorf("error inserting das documento: %w", classifyError(tt.err))
+
+			for _, kind := range []error{ErrDuplicate, ErrForeignKey, ErrCheckViolation} {
+				if got := errors.Is(err, kind); got != (kind == tt.kind) {
+					t.Errorf("errors.Is(err, %v) = %v", kind, got)
+				}
+			}
+			if got := ConstraintName(err); got != tt.constraint {
+				t.Errorf("ConstraintName = %q, want %q", got, tt.constraint)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("original error should remain in the chain")
+			}
+		})
+	}
+}
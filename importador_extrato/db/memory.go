@@ -0,0 +1,573 @@
+package db
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
+	"github.com/google/uuid"
+)
+
+// MemoryRepository é um Repository em memória, para testar a importação sem PostgreSQL.
+// Aplica as mesmas regras de unicidade e CHECKs do banco: fingerprint por conta,
+// uq_das_empresa_periodo, uq_das_numero_documento, valor > 0 e ck_das_periodo_dia1.
+type MemoryRepository struct {
+	mu             sync.Mutex
+	empresas       map[string]string   // cnpj -> id
+	contas         map[string][]string // numero, só com os dígitos -> ids
+	saldosIniciais map[string]money.Centavos
+	importacoes    map[string]*models.Importacao
+	transacoes     []*models.Transaction
+	das            []*models.DasDocumento
+	saldos         map[saldoKey]models.SaldoDiario
+	regras         []models.RegraCategorizacao
+	contaEmpresas  map[string]string // conta id -> empresa id
+	planoContas    []models.PlanoConta
+	contrapartes   map[string]*models.Contraparte // chave -> contraparte
+}
+
+// saldoKey é a chave primária de financeiro.saldos_diarios
+type saldoKey struct {
+	contaID string
+	data    string
+}
+
+func newSaldoKey(contaID string, data time.Time) saldoKey {
+	return saldoKey{contaID: contaID, data: data.Format("2006-01-02")}
+}
+
+// NewMemoryRepository cria um repositório vazio; empresas e contas são cadastradas com AddEmpresa e AddConta
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		empresas:       map[string]string{},
+		contas:         map[string][]string{},
+		saldosIniciais: map[string]money.Centavos{},
+		importacoes:    map[string]*models.Importacao{},
+		saldos:         map[saldoKey]models.SaldoDiario{},
+		contaEmpresas:  map[string]string{},
+		contrapartes:   map[string]*models.Contraparte{},
+	}
+}
+
+// AddEmpresa cadastra uma empresa ativa e retorna o seu id
+func (r *MemoryRepository) AddEmpresa(cnpj string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := uuid.Must(uuid.NewV7()).String()
+	r.empresas[cnpj] = id
+	return id
+}
+
+// AddConta cadastra uma conta ativa e retorna o seu id
+func (r *MemoryRepository) AddConta(numero string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := uuid.Must(uuid.NewV7()).String()
+	digitos := normalizeContaNumero(numero)
+	r.contas[digitos] = append(r.contas[digitos], id)
+	return id
+}
+
+// SetSaldoInicial define o saldo inicial da conta, base do saldo calculado dos saldos diários
+func (r *MemoryRepository) SetSaldoInicial(contaID string, saldo money.Centavos) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.saldosIniciais[contaID] = saldo
+}
+
+// AddRegra cadastra uma regra de categorização e retorna o seu id
+func (r *MemoryRepository) AddRegra(regra models.RegraCategorizacao) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	regra.ID = uuid.Must(uuid.NewV7()).String()
+	r.regras = append(r.regras, regra)
+	return regra.ID
+}
+
+// SetContaEmpresa define a empresa titular da conta, cujo plano de contas é usado na categorização
+func (r *MemoryRepository) SetContaEmpresa(contaID, empresaID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.contaEmpresas[contaID] = empresaID
+}
+
+// AddPlanoConta cadastra uma conta ativa no plano de contas da empresa e retorna o seu id
+func (r *MemoryRepository) AddPlanoConta(empresaID, codigo, nome, natureza string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	conta := models.PlanoConta{
+		ID:        uuid.Must(uuid.NewV7()).String(),
+		EmpresaID: empresaID,
+		Codigo:    codigo,
+		Nome:      nome,
+		Natureza:  natureza,
+		Ativa:     true,
+	}
+	for _, p := range r.planoContas {
+		if p.EmpresaID == empresaID && p.Codigo == conta.CodigoPai() {
+			conta.ParentID = &p.ID
+		}
+	}
+	r.planoContas = append(r.planoContas, conta)
+	return conta.ID
+}
+
+// Importacao retorna uma cópia da importação gravada, ou nil se ela não existir
+func (r *MemoryRepository) Importacao(id string) *models.Importacao {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	imp, ok := r.importacoes[id]
+	if !ok {
+		return nil
+	}
+	saved := *imp
+	return &saved
+}
+
+// Transactions retorna as transações confirmadas, na ordem em que foram gravadas
+func (r *MemoryRepository) Transactions() []*models.Transaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*models.Transaction(nil), r.transacoes...)
+}
+
+// DasDocumentos retorna os documentos DAS confirmados, na ordem em que foram gravados
+func (r *MemoryRepository) DasDocumentos() []*models.DasDocumento {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*models.DasDocumento(nil), r.das...)
+}
+
+// Contrapartes retorna as contrapartes confirmadas, por chave
+func (r *MemoryRepository) Contrapartes() map[string]models.Contraparte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	contrapartes := make(map[string]models.Contraparte, len(r.contrapartes))
+	for chave, c := range r.contrapartes {
+		contrapartes[chave] = *c
+	}
+	return contrapartes
+}
+
+// SaldosDiarios retorna os saldos diários confirmados da conta, em ordem cronológica
+func (r *MemoryRepository) SaldosDiarios(contaID string) []models.SaldoDiario {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var saldos []models.SaldoDiario
+	for key, s := range r.saldos {
+		if key.contaID == contaID {
+			saldos = append(saldos, s)
+		}
+	}
+	slices.SortFunc(saldos, func(a, b models.SaldoDiario) int { return a.Data.Compare(b.Data) })
+	return saldos
+}
+
+func (r *MemoryRepository) GetEmpresaIDByCNPJ(cnpj string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, ok := r.empresas[cnpj]
+	if !ok {
+		return "", fmt.Errorf("error finding active empresa by cnpj: no rows in result set")
+	}
+	return id, nil
+}
+
+func (r *MemoryRepository) GetContaIDByNumero(numero string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Como DB.findContaID
+	digitos := normalizeContaNumero(numero)
+	switch ids := r.contas[digitos]; {
+	case digitos == "":
+		return "", fmt.Errorf("error finding active conta by numero: no digits in numero %q", numero)
+	case len(ids) == 0:
+		return "", fmt.Errorf("error finding active conta by numero: no conta with numero %s", numero)
+	case len(ids) > 1:
+		return "", fmt.Errorf("error finding active conta by numero: more than one conta with numero %s", numero)
+	default:
+		return ids[0], nil
+	}
+}
+
+func (r *MemoryRepository) StartImportacao(arquivoNome, arquivoSHA256 string) (*models.Importacao, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	imp := newImportacao(arquivoNome, arquivoSHA256)
+	saved := *imp
+	r.importacoes[imp.ID] = &saved
+	return imp, nil
+}
+
+func (r *MemoryRepository) FinishImportacao(imp *models.Importacao) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.importacoes[imp.ID]; !ok {
+		return fmt.Errorf("error updating importacao: %s not found", imp.ID)
+	}
+
+	now := time.Now()
+	imp.FinalizadoEm = &now
+	imp.AtualizadoEm = now
+
+	saved := *imp
+	r.importacoes[imp.ID] = &saved
+	return nil
+}
+
+func (r *MemoryRepository) ListRegrasCategorizacao() ([]models.RegraCategorizacao, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.regras), nil
+}
+
+func (r *MemoryRepository) ListPlanoContasByConta(contaID string) ([]models.PlanoConta, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var contas []models.PlanoConta
+	for _, p := range r.planoContas {
+		if empresaID, ok := r.contaEmpresas[contaID]; ok && p.EmpresaID == empresaID {
+			contas = append(contas, p)
+		}
+	}
+	return contas, nil
+}
+
+func (r *MemoryRepository) ExistingFingerprints(contaID string, fingerprints []string) (map[string]bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wanted := make(map[string]bool, len(fingerprints))
+	for _, f := range fingerprints {
+		wanted[f] = true
+	}
+
+	existing := map[string]bool{}
+	for _, t := range r.transacoes {
+		if t.ContaID == contaID && wanted[t.Fingerprint] {
+			existing[t.Fingerprint] = true
+		}
+	}
+	return existing, nil
+}
+
+func (r *MemoryRepository) ImportacaoParsers(contaID string, from, to time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var parsers []string
+	for _, t := range r.transacoes {
+		if t.ContaID != contaID || t.Data.Before(from) || t.Data.After(to) || t.ImportacaoID == nil {
+			continue
+		}
+		if imp, ok := r.importacoes[*t.ImportacaoID]; ok && imp.Parser != nil && !slices.Contains(parsers, *imp.Parser) {
+			parsers = append(parsers, *imp.Parser)
+		}
+	}
+	slices.Sort(parsers)
+	return parsers, nil
+}
+
+func (r *MemoryRepository) DasDocumentoConflicts(empresaID string, periodoApuracao time.Time, numeroDocumento string) (numeroExists, periodoExists bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range r.das {
+		numeroExists = numeroExists || d.NumeroDocumento == numeroDocumento
+		periodoExists = periodoExists || (d.EmpresaID == empresaID && d.PeriodoApuracao.Equal(periodoApuracao))
+	}
+	return numeroExists, periodoExists, nil
+}
+
+// Begin abre uma transação. As transações não são isoladas entre si: o repositório atende a uma
+// importação por vez, como o comando import.
+func (r *MemoryRepository) Begin() (Tx, error) {
+	return &memoryTx{repo: r}, nil
+}
+
+// memoryTx acumula as gravações até o Commit
+type memoryTx struct {
+	repo         *MemoryRepository
+	transacoes   []*models.Transaction
+	das          []*models.DasDocumento
+	saldos       map[saldoKey]models.SaldoDiario // dias alterados nesta Tx
+	contrapartes map[string]*models.Contraparte  // contrapartes gravadas ou alteradas nesta Tx, por chave
+	done         bool
+}
+
+func (t *memoryTx) InsertTransactions(transacoes []*models.Transaction) (*InsertResult, error) {
+	if err := t.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	// Como no INSERT do lote, uma linha rejeitada impede a gravação de todas
+	for _, transacao := range transacoes {
+		if err := CheckTransaction(transacao); err != nil {
+			return nil, fmt.Errorf("error inserting transactions: %w", err)
+		}
+	}
+
+	result := &InsertResult{}
+	for _, transacao := range transacoes {
+		if t.fingerprintExists(transacao) {
+			result.Duplicates++
+			continue
+		}
+		t.transacoes = append(t.transacoes, transacao)
+		result.Inserted++
+	}
+	return result, nil
+}
+
+func (t *memoryTx) InsertTransaction(transacao *models.Transaction) (bool, error) {
+	if err := t.checkOpen(); err != nil {
+		return false, err
+	}
+	if err := CheckTransaction(transacao); err != nil {
+		return false, fmt.Errorf("error inserting transaction: %w", err)
+	}
+
+	if t.fingerprintExists(transacao) {
+		return false, nil
+	}
+	t.transacoes = append(t.transacoes, transacao)
+	return true, nil
+}
+
+func (t *memoryTx) UpsertContrapartes(contrapartes []*models.Contraparte) error {
+	if err := t.checkOpen(); err != nil {
+		return err
+	}
+
+	t.repo.mu.Lock()
+	defer t.repo.mu.Unlock()
+
+	if t.contrapartes == nil {
+		t.contrapartes = map[string]*models.Contraparte{}
+	}
+	for _, c := range uniqueContrapartes(contrapartes) {
+		saved, ok := t.contrapartes[c.Chave]
+		if !ok {
+			saved, ok = t.repo.contrapartes[c.Chave]
+		}
+		if !ok {
+			stored := *c
+			t.contrapartes[c.Chave] = &stored
+			continue
+		}
+
+		// Como o ON CONFLICT DO UPDATE: mantém os dados bancários não informados
+		updated := *saved
+		updated.BancoNome = cmp.Or(c.BancoNome, saved.BancoNome)
+		updated.BancoCodigo = cmp.Or(c.BancoCodigo, saved.BancoCodigo)
+		updated.ISPB = cmp.Or(c.ISPB, saved.ISPB)
+		updated.Agencia = cmp.Or(c.Agencia, saved.Agencia)
+		updated.Conta = cmp.Or(c.Conta, saved.Conta)
+		t.contrapartes[c.Chave] = &updated
+	}
+
+	for _, c := range contrapartes {
+		if c != nil {
+			c.ID = t.contrapartes[c.Chave].ID
+		}
+	}
+	return nil
+}
+
+func (t *memoryTx) InsertDasDocumento(importacaoID, empresaID string, periodoApuracao, dataVencimento time.Time, numeroDocumento string, valorTotal money.Centavos) (bool, error) {
+	if err := t.checkOpen(); err != nil {
+		return false, err
+	}
+
+	// Como em DB.InsertDasDocumento, o documento já gravado para a empresa é ignorado antes do INSERT
+	existing := t.allDas()
+	for _, d := range existing {
+		if d.NumeroDocumento == numeroDocumento && d.EmpresaID == empresaID {
+			return false, nil
+		}
+	}
+
+	if periodoApuracao.Day() != 1 {
+		return false, memoryConstraintError(ErrCheckViolation, "ck_das_periodo_dia1")
+	}
+	for _, d := range existing {
+		switch {
+		case d.NumeroDocumento == numeroDocumento:
+			return false, memoryConstraintError(ErrDuplicate, "uq_das_numero_documento")
+		case d.EmpresaID == empresaID && d.PeriodoApuracao.Equal(periodoApuracao):
+			return false, memoryConstraintError(ErrDuplicate, "uq_das_empresa_periodo")
+		}
+	}
+
+	t.das = append(t.das, newDasDocumento(importacaoID, empresaID, periodoApuracao, dataVencimento, numeroDocumento, valorTotal))
+	return true, nil
+}
+
+func (t *memoryTx) UpsertSaldosDiarios(contaID, importacaoID string, saldos []SaldoDiarioInput) ([]models.SaldoDiario, error) {
+	if err := t.checkOpen(); err != nil {
+		return nil, err
+	}
+	if len(saldos) == 0 {
+		return nil, nil
+	}
+
+	t.repo.mu.Lock()
+	defer t.repo.mu.Unlock()
+
+	if t.saldos == nil {
+		t.saldos = map[saldoKey]models.SaldoDiario{}
+	}
+	current := func(key saldoKey) (models.SaldoDiario, bool) {
+		if s, ok := t.saldos[key]; ok {
+			return s, true
+		}
+		s, ok := t.repo.saldos[key]
+		return s, ok
+	}
+
+	now := time.Now()
+	from := saldos[0].Data
+	for _, in := range saldos {
+		key := newSaldoKey(contaID, in.Data)
+		s, ok := current(key)
+		if !ok {
+			s = models.SaldoDiario{ContaID: contaID, Data: in.Data, CriadoEm: now}
+		}
+		if in.SaldoExtrato != nil {
+			saldo := *in.SaldoExtrato
+			s.SaldoExtrato = &saldo
+		}
+		s.ImportacaoID = &importacaoID
+		s.AtualizadoEm = now
+		t.saldos[key] = s
+
+		if in.Data.Before(from) {
+			from = in.Data
+		}
+	}
+
+	// Como recomputeSaldosCalculados: todos os dias da conta a partir do primeiro dia do extrato
+	transacoes := append(slices.Clone(t.repo.transacoes), t.transacoes...)
+	keys := slices.Collect(maps.Keys(t.repo.saldos))
+	keys = append(keys, slices.Collect(maps.Keys(t.saldos))...)
+	for _, key := range keys {
+		s, _ := current(key)
+		if key.contaID != contaID || s.Data.Before(from) {
+			continue
+		}
+		s.SaldoCalculado = t.repo.saldosIniciais[contaID]
+		for _, tr := range transacoes {
+			if tr.ContaID != contaID || tr.Data.After(s.Data) {
+				continue
+			}
+			if tr.TipoOperacao == "credito" {
+				s.SaldoCalculado += tr.Valor
+			} else {
+				s.SaldoCalculado -= tr.Valor
+			}
+		}
+		s.Divergente = s.SaldoExtrato != nil && *s.SaldoExtrato != s.SaldoCalculado
+		t.saldos[key] = s
+	}
+
+	saved := make([]models.SaldoDiario, 0, len(saldos))
+	for _, in := range saldos {
+		saved = append(saved, t.saldos[newSaldoKey(contaID, in.Data)])
+	}
+	slices.SortFunc(saved, func(a, b models.SaldoDiario) int { return a.Data.Compare(b.Data) })
+	return saved, nil
+}
+
+func (t *memoryTx) Savepoint(fn func() error) error {
+	transacoes, das, saldos, contrapartes := len(t.transacoes), len(t.das), maps.Clone(t.saldos), maps.Clone(t.contrapartes)
+	if err := fn(); err != nil {
+		t.transacoes, t.das, t.saldos, t.contrapartes = t.transacoes[:transacoes], t.das[:das], saldos, contrapartes
+		return err
+	}
+	return nil
+}
+
+func (t *memoryTx) Commit() error {
+	if err := t.checkOpen(); err != nil {
+		return err
+	}
+	t.done = true
+
+	t.repo.mu.Lock()
+	defer t.repo.mu.Unlock()
+
+	t.repo.transacoes = append(t.repo.transacoes, t.transacoes...)
+	t.repo.das = append(t.repo.das, t.das...)
+	maps.Copy(t.repo.saldos, t.saldos)
+	maps.Copy(t.repo.contrapartes, t.contrapartes)
+	return nil
+}
+
+// Rollback descarta as gravações; depois do Commit não faz nada, como sql.Tx
+func (t *memoryTx) Rollback() error {
+	t.done = true
+	t.transacoes, t.das, t.saldos, t.contrapartes = nil, nil, nil, nil
+	return nil
+}
+
+func (t *memoryTx) checkOpen() error {
+	if t.done {
+		return fmt.Errorf("transaction has already been committed or rolled back")
+	}
+	return nil
+}
+
+// fingerprintExists verifica a unicidade (conta_id, fingerprint) nas transações confirmadas e nesta Tx
+func (t *memoryTx) fingerprintExists(transacao *models.Transaction) bool {
+	t.repo.mu.Lock()
+	defer t.repo.mu.Unlock()
+
+	for _, list := range [][]*models.Transaction{t.repo.transacoes, t.transacoes} {
+		for _, other := range list {
+			if other.ContaID == transacao.ContaID && other.Fingerprint == transacao.Fingerprint {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allDas retorna os documentos confirmados e os gravados nesta Tx
+func (t *memoryTx) allDas() []*models.DasDocumento {
+	t.repo.mu.Lock()
+	defer t.repo.mu.Unlock()
+
+	return append(append([]*models.DasDocumento(nil), t.repo.das...), t.das...)
+}
+
+// memoryConstraintError monta o erro que o banco retornaria ao violar a restrição do documento DAS
+func memoryConstraintError(kind error, constraint string) error {
+	err := &ConstraintError{
+		Kind:       kind,
+		Constraint: constraint,
+		Err:        fmt.Errorf("%v %q", kind, constraint),
+	}
+	return fmt.Errorf("error inserting das documento: %w", err)
+}
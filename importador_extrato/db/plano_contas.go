@@ -0,0 +1,115 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/google/uuid"
+)
+
+const planoContaColumns = `id, empresa_id, parent_id, codigo, nome, natureza, ativa, criado_em, atualizado_em`
+
+// planoContasOrder ordena as contas pelo código numericamente, com cada conta antes das filhas ("4.2" < "4.10")
+const planoContasOrder = `ORDER BY string_to_array(codigo, '.')::int[]`
+
+// ListPlanoContas retorna o plano de contas da empresa, ativas ou não, em ordem de código
+func (db *DB) ListPlanoContas(empresaID string) ([]models.PlanoConta, error) {
+	var contas []models.PlanoConta
+	err := db.Select(&contas, `SELECT `+planoContaColumns+` FROM financeiro.plano_contas WHERE empresa_id = $1 `+planoContasOrder, empresaID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing plano de contas: %v", err)
+	}
+	return contas, nil
+}
+
+// ListPlanoContasByConta retorna o plano de contas da empresa titular da conta bancária
+func (db *DB) ListPlanoContasByConta(contaID string) ([]models.PlanoConta, error) {
+	query := `SELECT ` + planoContaColumns + ` FROM financeiro.plano_contas
+WHERE empresa_id = (SELECT empresa_id FROM financeiro.contas WHERE id = $1) ` + planoContasOrder
+	var contas []models.PlanoConta
+	if err := db.Select(&contas, query, contaID); err != nil {
+		return nil, fmt.Errorf("error listing plano de contas: %v", err)
+	}
+	return contas, nil
+}
+
+// InsertPlanoConta cria a conta no plano da empresa, abaixo da conta pai indicada pelo código.
+// Sem natureza, a conta herda a da conta pai.
+func (db *DB) InsertPlanoConta(conta *models.PlanoConta) error {
+	if codigoPai := conta.CodigoPai(); codigoPai != "" {
+		var parent models.PlanoConta
+		err := db.Get(&parent, `SELECT `+planoContaColumns+` FROM financeiro.plano_contas WHERE empresa_id = $1 AND codigo = $2`,
+			conta.EmpresaID, codigoPai)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("parent account %s not found", codigoPai)
+		}
+		if err != nil {
+			return fmt.Errorf("error finding parent account %s: %v", codigoPai, err)
+		}
+		conta.ParentID = &parent.ID
+		if conta.Natureza == "" {
+			conta.Natureza = parent.Natureza
+		}
+	}
+	if conta.Natureza == "" {
+		return fmt.Errorf("natureza is required for top-level account %s", conta.Codigo)
+	}
+
+	now := time.Now()
+	conta.ID = uuid.Must(uuid.NewV7()).String()
+	conta.Ativa = true
+	conta.CriadoEm, conta.AtualizadoEm = now, now
+
+	query := `
+INSERT INTO financeiro.plano_contas (` + planoContaColumns + `)
+VALUES (:id, :empresa_id, :parent_id, :codigo, :nome, :natureza, :ativa, :criado_em, :atualizado_em)
+`
+	if _, err := db.NamedExec(query, conta); err != nil {
+		return fmt.Errorf("error inserting plano de contas: %w", classifyError(err))
+	}
+	return nil
+}
+
+// UpdatePlanoConta altera o nome e a situação da conta do plano da empresa
+func (db *DB) UpdatePlanoConta(empresaID, codigo, nome string, ativa bool) error {
+	result, err := db.Exec(`UPDATE financeiro.plano_contas SET nome = $3, ativa = $4, atualizado_em = NOW() WHERE empresa_id = $1 AND codigo = $2`,
+		empresaID, codigo, nome, ativa)
+	if err != nil {
+		return fmt.Errorf("error updating plano de contas: %w", classifyError(err))
+	}
+	return checkPlanoContaAffected(result, codigo)
+}
+
+// DeletePlanoConta remove a conta do plano da empresa. Contas com subcontas ou transações
+// não podem ser removidas (ErrForeignKey); desative-as com UpdatePlanoConta.
+func (db *DB) DeletePlanoConta(empresaID, codigo string) error {
+	result, err := db.Exec(`DELETE FROM financeiro.plano_contas WHERE empresa_id = $1 AND codigo = $2`, empresaID, codigo)
+	if err != nil {
+		return fmt.Errorf("error deleting plano de contas: %w", classifyError(err))
+	}
+	return checkPlanoContaAffected(result, codigo)
+}
+
+func checkPlanoContaAffected(result sql.Result, codigo string) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error updating plano de contas: %v", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("account %s not found", codigo)
+	}
+	return nil
+}
+
+// SeedPlanoContas cria na empresa as contas do plano padrão (financeiro.seed_plano_contas) que
+// ela ainda não tem. Retorna quantas foram criadas.
+func (db *DB) SeedPlanoContas(empresaID string) (int, error) {
+	var inserted int
+	if err := db.Get(&inserted, `SELECT financeiro.seed_plano_contas($1)`, empresaID); err != nil {
+		return 0, fmt.Errorf("error seeding plano de contas: %v", err)
+	}
+	return inserted, nil
+}
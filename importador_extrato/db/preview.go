@@ -0,0 +1,78 @@
+package db
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/lib/pq"
+)
+
+// tituloMaxLen é o tamanho da coluna financeiro.transacoes.titulo
+const tituloMaxLen = 150
+
+// CheckTransaction verifica a transação contra as restrições da tabela financeiro.transacoes,
+// antecipando o erro que o banco retornaria ao gravá-la
+func CheckTransaction(t *models.Transaction) error {
+	if t.Valor <= 0 {
+		return &ConstraintError{
+			Kind:       ErrCheckViolation,
+			Constraint: "transacoes_valor_check",
+			Err:        fmt.Errorf("valor must be greater than zero (got %s)", t.Valor),
+		}
+	}
+	if n := utf8.RuneCountInString(t.Titulo); n > tituloMaxLen {
+		return fmt.Errorf("titulo has %d characters (max %d)", n, tituloMaxLen)
+	}
+	return nil
+}
+
+// ExistingFingerprints retorna quais dos fingerprints já estão gravados na conta
+func (db *DB) ExistingFingerprints(contaID string, fingerprints []string) (map[string]bool, error) {
+	query := `SELECT fingerprint FROM financeiro.transacoes WHERE conta_id = $1 AND fingerprint = ANY($2)`
+	var found []string
+	err := db.Select(&found, query, contaID, pq.Array(fingerprints))
+	if err != nil {
+		return nil, fmt.Errorf("error finding existing fingerprints: %v", err)
+	}
+
+	existing := make(map[string]bool, len(found))
+	for _, f := range found {
+		existing[f] = true
+	}
+	return existing, nil
+}
+
+// ImportacaoParsers retorna os parsers das importações que gravaram transações da conta no
+// intervalo [from, to], em ordem alfabética
+func (db *DB) ImportacaoParsers(contaID string, from, to time.Time) ([]string, error) {
+	query := `
+SELECT DISTINCT i.parser
+FROM financeiro.transacoes t
+JOIN financeiro.importacoes i ON i.id = t.importacao_id
+WHERE t.conta_id = $1 AND t.data BETWEEN $2 AND $3 AND i.parser IS NOT NULL
+ORDER BY i.parser
+`
+	var parsers []string
+	if err := db.Select(&parsers, query, contaID, from, to); err != nil {
+		return nil, fmt.Errorf("error listing parsers of importacoes: %v", err)
+	}
+	return parsers, nil
+}
+
+// DasDocumentoConflicts verifica se o número do documento já foi gravado, para qualquer empresa
+// (uq_das_numero_documento), e se a empresa já tem outro documento no mesmo período de apuração
+// (uq_das_empresa_periodo)
+func (db *DB) DasDocumentoConflicts(empresaID string, periodoApuracao time.Time, numeroDocumento string) (numeroExists, periodoExists bool, err error) {
+	query := `
+SELECT
+  EXISTS(SELECT 1 FROM financeiro.das_documentos WHERE numero_documento = $2),
+  EXISTS(SELECT 1 FROM financeiro.das_documentos WHERE empresa_id = $1 AND periodo_apuracao = $3)
+`
+	err = db.QueryRow(query, empresaID, numeroDocumento, periodoApuracao).Scan(&numeroExists, &periodoExists)
+	if err != nil {
+		return false, false, fmt.Errorf("error checking for existing das documento: %v", err)
+	}
+	return numeroExists, periodoExists, nil
+}
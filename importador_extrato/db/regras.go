@@ -0,0 +1,102 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/lib/pq"
+)
+
+const regraColumns = `id, nome, prioridade, ativa, titulo_regex, descricao_regex, documento,
+valor_min, valor_max, conta_id, tipo_operacao, categoria, subcategoria, plano_conta_codigo,
+criado_em, atualizado_em`
+
+// ListRegrasCategorizacao retorna as regras de categorização, ativas ou não, em ordem de prioridade
+func (db *DB) ListRegrasCategorizacao() ([]models.RegraCategorizacao, error) {
+	var regras []models.RegraCategorizacao
+	err := db.Select(&regras, `SELECT `+regraColumns+` FROM financeiro.regras_categorizacao ORDER BY prioridade, nome`)
+	if err != nil {
+		return nil, fmt.Errorf("error listing regras de categorizacao: %v", err)
+	}
+	return regras, nil
+}
+
+// ListTransactions retorna as transações no intervalo [from, to], em ordem de data.
+// Com contaNumero vazio considera todas as contas.
+func (db *DB) ListTransactions(from, to time.Time, contaNumero string) ([]models.Transaction, error) {
+	var contaID *string
+	if contaNumero != "" {
+		id, err := db.findContaID(contaNumero, false)
+		if err != nil {
+			return nil, fmt.Errorf("error finding conta by numero: %v", err)
+		}
+		contaID = &id
+	}
+
+	query := `
+SELECT ` + transacaoColumns + `
+FROM financeiro.transacoes
+WHERE data BETWEEN $1 AND $2
+  AND ($3::uuid IS NULL OR conta_id = $3)
+ORDER BY data, id
+`
+	var transacoes []models.Transaction
+	if err := db.Select(&transacoes, query, from, to, contaID); err != nil {
+		return nil, fmt.Errorf("error listing transacoes: %v", err)
+	}
+	return transacoes, nil
+}
+
+// UpdateCategorias grava categoria, subcategoria, regra e conta do plano das transações, em uma
+// única transação. Transações categorizadas manualmente (categoria ou conta do plano sem regra)
+// não são alteradas, e atualizado_em é mantido: a recategorização por regras não conta como
+// modificação da transação importada.
+// Retorna o número de transações alteradas.
+func (db *DB) UpdateCategorias(transacoes []models.Transaction) (int, error) {
+	if len(transacoes) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, len(transacoes))
+	categorias := make([]sql.NullString, len(transacoes))
+	subcategorias := make([]sql.NullString, len(transacoes))
+	regras := make([]sql.NullString, len(transacoes))
+	planoContas := make([]sql.NullString, len(transacoes))
+	for i, t := range transacoes {
+		ids[i] = t.ID
+		categorias[i], subcategorias[i] = nullString(t.Categoria), nullString(t.Subcategoria)
+		regras[i], planoContas[i] = nullString(t.RegraID), nullString(t.PlanoContaID)
+	}
+
+	query := `
+UPDATE financeiro.transacoes t
+SET categoria = u.categoria, subcategoria = u.subcategoria, regra_id = u.regra_id, plano_conta_id = u.plano_conta_id
+FROM UNNEST($1::uuid[], $2::text[], $3::text[], $4::uuid[], $5::uuid[]) AS u(id, categoria, subcategoria, regra_id, plano_conta_id)
+WHERE t.id = u.id
+  AND (t.regra_id IS NOT NULL OR (t.categoria IS NULL AND t.plano_conta_id IS NULL))
+  AND (t.categoria, t.subcategoria, t.regra_id, t.plano_conta_id)
+      IS DISTINCT FROM (u.categoria, u.subcategoria, u.regra_id, u.plano_conta_id)
+`
+	result, err := db.Exec(query, pq.Array(ids), pq.Array(categorias), pq.Array(subcategorias), pq.Array(regras), pq.Array(planoContas))
+	if err != nil {
+		return 0, fmt.Errorf("error updating categorias: %w", classifyError(err))
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error updating categorias: %v", err)
+	}
+
+	db.logger.Debug("categorias updated", "transacoes", len(transacoes), "updated", updated)
+
+	return int(updated), nil
+}
+
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
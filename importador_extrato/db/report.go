@@ -0,0 +1,56 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
+)
+
+// ResumoMensal totaliza as transações de uma conta em um mês
+type ResumoMensal struct {
+	Mes        time.Time      `db:"mes"`
+	Conta      string         `db:"conta"`
+	Quantidade int            `db:"quantidade"`
+	Creditos   money.Centavos `db:"creditos"`
+	Debitos    money.Centavos `db:"debitos"`
+}
+
+// Saldo retorna créditos menos débitos
+func (r ResumoMensal) Saldo() money.Centavos {
+	return r.Creditos - r.Debitos
+}
+
+// MonthlySummary totaliza as transações por conta e mês no intervalo [from, to].
+// Com contaNumero vazio considera todas as contas.
+func (db *DB) MonthlySummary(from, to time.Time, contaNumero string) ([]ResumoMensal, error) {
+	var contaID *string
+	if contaNumero != "" {
+		id, err := db.findContaID(contaNumero, false)
+		if err != nil {
+			return nil, fmt.Errorf("error finding conta by numero: %v", err)
+		}
+		contaID = &id
+	}
+
+	query := `
+SELECT
+  DATE_TRUNC('month', t.data)::date AS mes,
+  c.nome AS conta,
+  COUNT(*) AS quantidade,
+  COALESCE(SUM(t.valor) FILTER (WHERE t.tipo_operacao = 'credito'), 0) AS creditos,
+  COALESCE(SUM(t.valor) FILTER (WHERE t.tipo_operacao = 'debito'), 0) AS debitos
+FROM financeiro.transacoes t
+JOIN financeiro.contas c ON c.id = t.conta_id
+WHERE t.data BETWEEN $1 AND $2
+  AND ($3::uuid IS NULL OR c.id = $3)
+GROUP BY 1, 2
+ORDER BY 1, 2
+`
+	var resumo []ResumoMensal
+	err := db.Select(&resumo, query, from, to, contaID)
+	if err != nil {
+		return nil, fmt.Errorf("error summarizing transacoes: %v", err)
+	}
+	return resumo, nil
+}
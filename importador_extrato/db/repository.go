@@ -0,0 +1,120 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository são as operações do banco usadas pela importação: busca de empresas e contas,
+// registro das importações e consultas do dry run. As gravações de transações e documentos DAS
+// são feitas em uma Tx, aberta com Begin.
+// DB é a implementação sobre o PostgreSQL; MemoryRepository mantém os dados em memória, para testes.
+type Repository interface {
+	GetEmpresaIDByCNPJ(cnpj string) (string, error)
+	GetContaIDByNumero(numero string) (string, error)
+
+	// StartImportacao e FinishImportacao registram a importação fora da Tx do arquivo,
+	// para que a falha fique registrada mesmo quando a Tx é desfeita
+	StartImportacao(arquivoNome, arquivoSHA256 string) (*models.Importacao, error)
+	FinishImportacao(imp *models.Importacao) error
+
+	// ListRegrasCategorizacao retorna as regras de categorização aplicadas às transações importadas
+	ListRegrasCategorizacao() ([]models.RegraCategorizacao, error)
+
+	// ListPlanoContasByConta retorna o plano de contas da empresa titular da conta bancária,
+	// em que são resolvidos os códigos do plano das regras
+	ListPlanoContasByConta(contaID string) ([]models.PlanoConta, error)
+
+	ExistingFingerprints(contaID string, fingerprints []string) (map[string]bool, error)
+
+	// ImportacaoParsers retorna os parsers das importações que gravaram transações da conta no
+	// intervalo [from, to]
+	ImportacaoParsers(contaID string, from, to time.Time) ([]string, error)
+	// DasDocumentoConflicts antecipa as restrições únicas de financeiro.das_documentos: o número do
+	// documento em qualquer empresa e o período de apuração na empresa
+	DasDocumentoConflicts(empresaID string, periodoApuracao time.Time, numeroDocumento string) (numeroExists, periodoExists bool, err error)
+
+	Begin() (Tx, error)
+}
+
+// Tx é uma transação do banco em que o arquivo é gravado. Nada é visível fora dela até o Commit.
+type Tx interface {
+	// InsertTransactions grava o lote inteiro ou nada, ignorando as transações já existentes na conta
+	InsertTransactions(transacoes []*models.Transaction) (*InsertResult, error)
+
+	// InsertTransaction grava uma transação; retorna false se a conta já tiver o mesmo fingerprint
+	InsertTransaction(transacao *models.Transaction) (bool, error)
+
+	// UpsertSaldosDiarios grava os saldos de fim de dia da conta e recalcula o saldo calculado;
+	// retorna os dias como ficaram gravados
+	UpsertSaldosDiarios(contaID, importacaoID string, saldos []SaldoDiarioInput) ([]models.SaldoDiario, error)
+
+	// UpsertContrapartes grava as contrapartes novas e atualiza os dados bancários das já cadastradas;
+	// o ID de cada contraparte passa a ser o do registro gravado
+	UpsertContrapartes(contrapartes []*models.Contraparte) error
+
+	// InsertDasDocumento grava o documento; retorna false se a empresa já tiver o mesmo número de documento
+	InsertDasDocumento(importacaoID, empresaID string, periodoApuracao, dataVencimento time.Time, numeroDocumento string, valorTotal money.Centavos) (bool, error)
+
+	// Savepoint executa fn desfazendo apenas o que fn gravou em caso de erro
+	Savepoint(fn func() error) error
+
+	Commit() error
+	Rollback() error
+}
+
+var (
+	_ Repository = (*DB)(nil)
+	_ Repository = (*MemoryRepository)(nil)
+)
+
+// Begin abre a transação em que o arquivo é gravado
+func (db *DB) Begin() (Tx, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	return &sqlTx{db: db, tx: tx}, nil
+}
+
+// sqlTx é a Tx sobre uma transação do PostgreSQL
+type sqlTx struct {
+	db *DB
+	tx *sqlx.Tx
+}
+
+func (t *sqlTx) InsertTransactions(transacoes []*models.Transaction) (*InsertResult, error) {
+	return t.db.InsertTransactions(t.tx, transacoes)
+}
+
+func (t *sqlTx) InsertTransaction(transacao *models.Transaction) (bool, error) {
+	return t.db.InsertTransaction(t.tx, transacao)
+}
+
+func (t *sqlTx) UpsertSaldosDiarios(contaID, importacaoID string, saldos []SaldoDiarioInput) ([]models.SaldoDiario, error) {
+	return t.db.UpsertSaldosDiarios(t.tx, contaID, importacaoID, saldos)
+}
+
+func (t *sqlTx) UpsertContrapartes(contrapartes []*models.Contraparte) error {
+	return t.db.UpsertContrapartes(t.tx, contrapartes)
+}
+
+func (t *sqlTx) InsertDasDocumento(importacaoID, empresaID string, periodoApuracao, dataVencimento time.Time, numeroDocumento string, valorTotal money.Centavos) (bool, error) {
+	return t.db.InsertDasDocumento(t.tx, importacaoID, empresaID, periodoApuracao, dataVencimento, numeroDocumento, valorTotal)
+}
+
+func (t *sqlTx) Savepoint(fn func() error) error {
+	return WithSavepoint(t.tx, fn)
+}
+
+func (t *sqlTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *sqlTx) Rollback() error {
+	return t.tx.Rollback()
+}
@@ -0,0 +1,232 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// RevertPlan lista os registros criados por uma importação e que seriam removidos ao revertê-la
+type RevertPlan struct {
+	Importacao    models.Importacao
+	Transacoes    []models.Transaction
+	DasDocumentos []models.DasDocumento
+
+	// DasPagos são os documentos DAS de outras importações pagos por transações desta, que voltam a
+	// EMITIDO ao revertê-la
+	DasPagos []models.DasDocumento
+
+	// Transferencias são as transferências internas de que participam transações da importação,
+	// desfeitas ao revertê-la
+	Transferencias []models.TransferenciaInterna
+
+	// Modificados conta os registros alterados depois da importação (categorizados, conciliados, pagos)
+	Modificados int
+}
+
+// transacaoColumns são as colunas de financeiro.transacoes lidas em models.Transaction
+const transacaoColumns = `id, conta_id, data, titulo, COALESCE(descricao, '') AS descricao,
+tipo_operacao, tipo_transacao, valor,
+criado_em, atualizado_em, fingerprint, importacao_id,
+categoria, subcategoria, regra_id, plano_conta_id, contraparte_id`
+
+const importacaoColumns = `id, arquivo_nome, arquivo_sha256, parser, conta_id, empresa_id,
+status, mensagem_erro, qtd_importadas, qtd_ignoradas, qtd_falhas,
+iniciado_em, finalizado_em, criado_em, atualizado_em`
+
+// dasColumns são as colunas de financeiro.das_documentos lidas em models.DasDocumento
+const dasColumns = `id, empresa_id, periodo_apuracao, data_vencimento, numero_documento, valor_total,
+status, arquivo_path, criado_em, atualizado_em, importacao_id, transacao_id`
+
+func (db *DB) ListImportacoes(limit int) ([]models.Importacao, error) {
+	query := `SELECT ` + importacaoColumns + ` FROM financeiro.importacoes ORDER BY iniciado_em DESC LIMIT $1`
+	var importacoes []models.Importacao
+	err := db.Select(&importacoes, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing importacoes: %v", err)
+	}
+	return importacoes, nil
+}
+
+// PlanRevert retorna o que seria removido ao reverter a importação, sem alterar nada
+func (db *DB) PlanRevert(importacaoID string) (*RevertPlan, error) {
+	return planRevert(db, importacaoID, false)
+}
+
+// RevertImportacao remove, em uma única transação, as transações e documentos DAS
+// criados pela importação e desfaz os saldos diários que ela gravou. Registros modificados depois da importação só são
+// removidos com force.
+func (db *DB) RevertImportacao(importacaoID string, force bool) (*RevertPlan, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	plan, err := planRevert(tx, importacaoID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := plan.CheckRevertable(); err != nil {
+		return nil, err
+	}
+
+	if plan.Modificados > 0 && !force {
+		return plan, fmt.Errorf("importacao %s has %d row(s) modified after import; use force to revert anyway", importacaoID, plan.Modificados)
+	}
+
+	// As transferências internas também contam em plan.Modificados; a chave estrangeira impede
+	// remover as transações antes delas
+	_, err = tx.Exec(`
+DELETE FROM financeiro.transferencias_internas
+WHERE debito_id IN (SELECT id FROM financeiro.transacoes WHERE importacao_id = $1)
+   OR credito_id IN (SELECT id FROM financeiro.transacoes WHERE importacao_id = $1)`,
+		importacaoID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting transferencias internas: %v", err)
+	}
+
+	// Documentos DAS pagos por transações da importação voltam a aguardar a conciliação. Eles contam
+	// em plan.Modificados, então só chegam aqui com force.
+	_, err = tx.Exec(`
+UPDATE financeiro.das_documentos
+SET status = $2, transacao_id = NULL, atualizado_em = $3
+WHERE transacao_id IN (SELECT id FROM financeiro.transacoes WHERE importacao_id = $1)`,
+		importacaoID, models.DasEmitido, time.Now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error resetting paid das documentos: %v", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM financeiro.transacoes WHERE importacao_id = $1`, importacaoID)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting transacoes: %v", err)
+	}
+
+	if err := revertSaldosDiarios(tx, importacaoID, plan.Transacoes); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`DELETE FROM financeiro.das_documentos WHERE importacao_id = $1`, importacaoID)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting das documentos: %v", err)
+	}
+
+	_, err = tx.Exec(
+		`UPDATE financeiro.importacoes SET status = $2, atualizado_em = $3 WHERE id = $1`,
+		importacaoID, models.ImportacaoRevertida, time.Now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error updating importacao: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing revert: %v", err)
+	}
+
+	db.logger.Info("importacao reverted",
+		"importacao_id", importacaoID,
+		"transacoes", len(plan.Transacoes),
+		"das_documentos", len(plan.DasDocumentos),
+		"transferencias_internas", len(plan.Transferencias))
+
+	return plan, nil
+}
+
+func planRevert(q sqlx.Queryer, importacaoID string, lock bool) (*RevertPlan, error) {
+	forUpdate := ""
+	if lock {
+		forUpdate = " FOR UPDATE"
+	}
+
+	plan := &RevertPlan{}
+
+	err := sqlx.Get(q, &plan.Importacao, `SELECT `+importacaoColumns+` FROM financeiro.importacoes WHERE id = $1`+forUpdate, importacaoID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding importacao %s: %v", importacaoID, err)
+	}
+
+	transacoesQuery := `SELECT ` + transacaoColumns + `
+FROM financeiro.transacoes
+WHERE importacao_id = $1
+ORDER BY data, id` + forUpdate
+
+	err = sqlx.Select(q, &plan.Transacoes, transacoesQuery, importacaoID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing transacoes of importacao: %v", err)
+	}
+
+	dasQuery := `
+SELECT ` + dasColumns + `
+FROM financeiro.das_documentos
+WHERE importacao_id = $1
+ORDER BY periodo_apuracao, id` + forUpdate
+
+	err = sqlx.Select(q, &plan.DasDocumentos, dasQuery, importacaoID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing das documentos of importacao: %v", err)
+	}
+
+	dasPagosQuery := `
+SELECT ` + dasColumns + `
+FROM financeiro.das_documentos
+WHERE transacao_id IN (SELECT id FROM financeiro.transacoes WHERE importacao_id = $1)
+  AND importacao_id IS DISTINCT FROM $1
+ORDER BY periodo_apuracao, id` + forUpdate
+
+	err = sqlx.Select(q, &plan.DasPagos, dasPagosQuery, importacaoID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing das documentos paid by importacao: %v", err)
+	}
+
+	transferenciasQuery := `
+SELECT id, debito_id, credito_id, criado_em
+FROM financeiro.transferencias_internas
+WHERE debito_id IN (SELECT id FROM financeiro.transacoes WHERE importacao_id = $1)
+   OR credito_id IN (SELECT id FROM financeiro.transacoes WHERE importacao_id = $1)
+ORDER BY criado_em, id` + forUpdate
+
+	err = sqlx.Select(q, &plan.Transferencias, transferenciasQuery, importacaoID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing transferencias internas of importacao: %v", err)
+	}
+
+	plan.contarModificados()
+
+	return plan, nil
+}
+
+// CheckRevertable verifica se a importação pode ser revertida: só importações CONCLUIDAS gravaram
+// algo. Uma importação com FALHA não gravou nada e continua registrada como falha; uma EM_ANDAMENTO
+// ainda não terminou (ou foi interrompida, e a transação dela desfeita).
+func (p *RevertPlan) CheckRevertable() error {
+	switch p.Importacao.Status {
+	case models.ImportacaoConcluida:
+		return nil
+	case models.ImportacaoRevertida:
+		return fmt.Errorf("importacao %s was already reverted", p.Importacao.ID)
+	}
+	return fmt.Errorf("importacao %s has status %s; only %s imports can be reverted",
+		p.Importacao.ID, p.Importacao.Status, models.ImportacaoConcluida)
+}
+
+// contarModificados conta os registros alterados depois da importação. A conciliação de um DAS
+// e as transferências internas com transações da importação contam como modificação, já que a
+// reversão as desfaz.
+func (p *RevertPlan) contarModificados() {
+	p.Modificados = len(p.DasPagos) + len(p.Transferencias)
+	for i := range p.Transacoes {
+		if p.Transacoes[i].Modificada() {
+			p.Modificados++
+		}
+	}
+	for i := range p.DasDocumentos {
+		if p.DasDocumentos[i].Modificado() {
+			p.Modificados++
+		}
+	}
+}
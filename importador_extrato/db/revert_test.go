@@ -0,0 +1,73 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+)
+
+func TestRevertPlanModificados(t *testing.T) {
+	criado := time.Date(2025, time.October, 1, 12, 0, 0, 0, time.UTC)
+	transacao := models.Transaction{ID: "t1", CriadoEm: criado, AtualizadoEm: criado}
+	transacaoID := transacao.ID
+	pago := models.DasDocumento{
+		ID: "das-1", Status: models.DasPago, CriadoEm: criado, AtualizadoEm: criado.Add(time.Hour), TransacaoID: &transacaoID,
+	}
+
+	tests := []struct {
+		name string
+		plan RevertPlan
+		want int
+	}{
+		{
+			name: "sem alterações",
+			plan: RevertPlan{Transacoes: []models.Transaction{transacao}},
+			want: 0,
+		},
+		{
+			name: "DAS de outra importação pago por uma transação da importação",
+			plan: RevertPlan{Transacoes: []models.Transaction{transacao}, DasPagos: []models.DasDocumento{pago}},
+			want: 1,
+		},
+		{
+			name: "transação em uma transferência interna",
+			plan: RevertPlan{
+				Transacoes:     []models.Transaction{transacao},
+				Transferencias: []models.TransferenciaInterna{{ID: "ti-1", DebitoID: "t1", CreditoID: "t2"}},
+			},
+			want: 1,
+		},
+		{
+			name: "DAS da própria importação pago",
+			plan: RevertPlan{DasDocumentos: []models.DasDocumento{pago}},
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.plan.contarModificados()
+			if tt.plan.Modificados != tt.want {
+				t.Errorf("Modificados = %d, want %d", tt.plan.Modificados, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevertPlanCheckRevertable(t *testing.T) {
+	tests := []struct {
+		status  string
+		wantErr bool
+	}{
+		{models.ImportacaoConcluida, false},
+		{models.ImportacaoFalha, true},
+		{models.ImportacaoEmAndamento, true},
+		{models.ImportacaoRevertida, true},
+	}
+	for _, tt := range tests {
+		plan := RevertPlan{Importacao: models.Importacao{ID: "importacao", Status: tt.status}}
+		if err := plan.CheckRevertable(); (err != nil) != tt.wantErr {
+			t.Errorf("CheckRevertable() with status %s = %v, wantErr %v", tt.status, err, tt.wantErr)
+		}
+	}
+}
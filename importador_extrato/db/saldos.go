@@ -0,0 +1,244 @@
+package db
+
+import (
+	"cmp"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// SaldoDiarioInput é um dia do extrato a gravar em financeiro.saldos_diarios
+type SaldoDiarioInput struct {
+	Data         time.Time
+	SaldoExtrato *money.Centavos // saldo de fim de dia informado pelo banco; nil se o extrato não traz saldo
+}
+
+const saldosDiariosColumns = `conta_id, data, saldo_extrato, saldo_calculado, divergente, importacao_id, criado_em, atualizado_em`
+
+// UpsertSaldosDiarios grava os dias do extrato em financeiro.saldos_diarios e recalcula o saldo
+// calculado deles e de todos os dias posteriores da conta, que também mudam com as transações
+// importadas. Um dia já gravado mantém o saldo do extrato anterior se o novo extrato não informar saldo.
+// Retorna os dias do extrato como ficaram gravados, com a indicação de divergência.
+func (db *DB) UpsertSaldosDiarios(tx *sqlx.Tx, contaID, importacaoID string, saldos []SaldoDiarioInput) ([]models.SaldoDiario, error) {
+	if len(saldos) == 0 {
+		return nil, nil
+	}
+
+	datas := make([]string, len(saldos))
+	extratos := make([]sql.NullString, len(saldos))
+	from := saldos[0].Data
+	for i, s := range saldos {
+		datas[i] = s.Data.Format("2006-01-02")
+		if s.SaldoExtrato != nil {
+			extratos[i] = sql.NullString{String: s.SaldoExtrato.String(), Valid: true}
+		}
+		if s.Data.Before(from) {
+			from = s.Data
+		}
+	}
+
+	// saldo_calculado é preenchido em seguida por recomputeSaldosCalculados
+	query := `
+INSERT INTO financeiro.saldos_diarios (
+conta_id, data, saldo_extrato, saldo_calculado, importacao_id, criado_em, atualizado_em
+)
+SELECT $1, d.data, d.saldo_extrato, 0, $2, NOW(), NOW()
+FROM UNNEST($3::date[], $4::numeric[]) AS d(data, saldo_extrato)
+ON CONFLICT (conta_id, data) DO UPDATE SET
+  saldo_extrato = COALESCE(EXCLUDED.saldo_extrato, saldos_diarios.saldo_extrato),
+  importacao_id = EXCLUDED.importacao_id,
+  atualizado_em = EXCLUDED.atualizado_em
+`
+	if _, err := tx.Exec(query, contaID, importacaoID, pq.Array(datas), pq.Array(extratos)); err != nil {
+		return nil, fmt.Errorf("error upserting saldos diarios: %w", classifyError(err))
+	}
+
+	// O saldo de cada importação fica guardado para a reversão
+	query = `
+INSERT INTO financeiro.saldos_diarios_importacoes (conta_id, data, importacao_id, saldo_extrato)
+SELECT $1, d.data, $2, d.saldo_extrato
+FROM UNNEST($3::date[], $4::numeric[]) AS d(data, saldo_extrato)
+ON CONFLICT (conta_id, data, importacao_id) DO UPDATE SET saldo_extrato = EXCLUDED.saldo_extrato
+`
+	if _, err := tx.Exec(query, contaID, importacaoID, pq.Array(datas), pq.Array(extratos)); err != nil {
+		return nil, fmt.Errorf("error recording saldos diarios of importacao: %w", classifyError(err))
+	}
+
+	if err := recomputeSaldosCalculados(tx, contaID, from); err != nil {
+		return nil, err
+	}
+
+	var saved []models.SaldoDiario
+	err := tx.Select(&saved, `SELECT `+saldosDiariosColumns+` FROM financeiro.saldos_diarios WHERE conta_id = $1 AND data = ANY($2::date[]) ORDER BY data`,
+		contaID, pq.Array(datas))
+	if err != nil {
+		return nil, fmt.Errorf("error listing saldos diarios: %v", err)
+	}
+
+	db.logger.Debug("saldos diarios updated", "conta_id", contaID, "days", len(saved))
+
+	return saved, nil
+}
+
+// recomputeSaldosCalculados recalcula o saldo calculado dos dias da conta a partir de from
+func recomputeSaldosCalculados(e sqlx.Execer, contaID string, from time.Time) error {
+	query := `
+UPDATE financeiro.saldos_diarios s
+SET saldo_calculado = c.saldo_inicial + COALESCE((
+      SELECT SUM(CASE WHEN t.tipo_operacao = 'credito' THEN t.valor ELSE -t.valor END)
+      FROM financeiro.transacoes t
+      WHERE t.conta_id = s.conta_id AND t.data <= s.data
+    ), 0)
+FROM financeiro.contas c
+WHERE c.id = s.conta_id
+  AND s.conta_id = $1
+  AND s.data >= $2
+`
+	if _, err := e.Exec(query, contaID, from); err != nil {
+		return fmt.Errorf("error recomputing saldos diarios: %v", err)
+	}
+	return nil
+}
+
+// saldoImportacao é o saldo de um dia informado pelo extrato de uma importação
+type saldoImportacao struct {
+	ContaID      string          `db:"conta_id"`
+	Data         time.Time       `db:"data"`
+	ImportacaoID string          `db:"importacao_id"`
+	SaldoExtrato *money.Centavos `db:"saldo_extrato"`
+	IniciadoEm   time.Time       `db:"iniciado_em"`
+}
+
+// revertSaldosDiarios desfaz os dias gravados pela importação: cada dia volta à última das outras
+// importações que o cobrem, com o último saldo do extrato que elas informaram, e os dias que
+// nenhuma outra cobre são removidos. Depois recalcula o saldo calculado das contas.
+func revertSaldosDiarios(e sqlx.Ext, importacaoID string, transacoes []models.Transaction) error {
+	var dias []saldoImportacao
+	err := sqlx.Select(e, &dias, `
+SELECT h.conta_id, h.data, h.importacao_id, h.saldo_extrato, i.iniciado_em
+FROM financeiro.saldos_diarios_importacoes h
+JOIN financeiro.importacoes i ON i.id = h.importacao_id
+WHERE h.importacao_id = $1`, importacaoID)
+	if err != nil {
+		return fmt.Errorf("error listing saldos diarios of importacao: %v", err)
+	}
+
+	var outras []saldoImportacao
+	err = sqlx.Select(e, &outras, `
+SELECT h.conta_id, h.data, h.importacao_id, h.saldo_extrato, i.iniciado_em
+FROM financeiro.saldos_diarios_importacoes h
+JOIN financeiro.importacoes i ON i.id = h.importacao_id
+WHERE h.importacao_id <> $1
+  AND (h.conta_id, h.data) IN (
+    SELECT conta_id, data FROM financeiro.saldos_diarios_importacoes WHERE importacao_id = $1
+  )`, importacaoID)
+	if err != nil {
+		return fmt.Errorf("error listing saldos diarios of other importacoes: %v", err)
+	}
+
+	restaurados, removidos := restoreSaldosDiarios(dias, outras)
+
+	if len(restaurados) > 0 {
+		contas, datas, importacoes, extratos := saldoArrays(restaurados)
+		_, err := e.Exec(`
+UPDATE financeiro.saldos_diarios s
+SET saldo_extrato = u.saldo_extrato, importacao_id = u.importacao_id, atualizado_em = NOW()
+FROM UNNEST($1::uuid[], $2::date[], $3::uuid[], $4::numeric[]) AS u(conta_id, data, importacao_id, saldo_extrato)
+WHERE s.conta_id = u.conta_id AND s.data = u.data`,
+			pq.Array(contas), pq.Array(datas), pq.Array(importacoes), pq.Array(extratos))
+		if err != nil {
+			return fmt.Errorf("error restoring saldos diarios: %v", err)
+		}
+	}
+
+	if len(removidos) > 0 {
+		contas, datas, _, _ := saldoArrays(removidos)
+		_, err := e.Exec(`
+DELETE FROM financeiro.saldos_diarios s
+USING UNNEST($1::uuid[], $2::date[]) AS u(conta_id, data)
+WHERE s.conta_id = u.conta_id AND s.data = u.data`,
+			pq.Array(contas), pq.Array(datas))
+		if err != nil {
+			return fmt.Errorf("error deleting saldos diarios: %v", err)
+		}
+	}
+
+	if _, err := e.Exec(`DELETE FROM financeiro.saldos_diarios_importacoes WHERE importacao_id = $1`, importacaoID); err != nil {
+		return fmt.Errorf("error deleting saldos diarios of importacao: %v", err)
+	}
+
+	// Primeira data alterada de cada conta: transação removida ou dia restaurado
+	from := map[string]time.Time{}
+	first := func(contaID string, data time.Time) {
+		if d, ok := from[contaID]; !ok || data.Before(d) {
+			from[contaID] = data
+		}
+	}
+	for _, t := range transacoes {
+		first(t.ContaID, t.Data)
+	}
+	for _, s := range restaurados {
+		first(s.ContaID, s.Data)
+	}
+
+	for contaID, data := range from {
+		if err := recomputeSaldosCalculados(e, contaID, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreSaldosDiarios decide o que fica de cada dia gravado pela importação revertida, dadas as
+// outras importações que gravaram os mesmos dias. Como em UpsertSaldosDiarios, o dia fica com a
+// última importação e com o último saldo do extrato informado. Retorna os dias a restaurar e os
+// dias a remover, que nenhuma outra importação cobre.
+func restoreSaldosDiarios(dias, outras []saldoImportacao) (restaurados, removidos []saldoImportacao) {
+	porDia := map[saldoKey][]saldoImportacao{}
+	for _, s := range outras {
+		key := newSaldoKey(s.ContaID, s.Data)
+		porDia[key] = append(porDia[key], s)
+	}
+
+	for _, dia := range dias {
+		cobertura := porDia[newSaldoKey(dia.ContaID, dia.Data)]
+		if len(cobertura) == 0 {
+			removidos = append(removidos, dia)
+			continue
+		}
+
+		slices.SortFunc(cobertura, func(a, b saldoImportacao) int {
+			return cmp.Or(a.IniciadoEm.Compare(b.IniciadoEm), cmp.Compare(a.ImportacaoID, b.ImportacaoID))
+		})
+		restaurado := saldoImportacao{ContaID: dia.ContaID, Data: dia.Data}
+		for _, s := range cobertura {
+			restaurado.ImportacaoID, restaurado.IniciadoEm = s.ImportacaoID, s.IniciadoEm
+			if s.SaldoExtrato != nil {
+				restaurado.SaldoExtrato = s.SaldoExtrato
+			}
+		}
+		restaurados = append(restaurados, restaurado)
+	}
+	return restaurados, removidos
+}
+
+// saldoArrays separa os dias em colunas para UNNEST
+func saldoArrays(saldos []saldoImportacao) (contas, datas, importacoes []string, extratos []sql.NullString) {
+	for _, s := range saldos {
+		contas = append(contas, s.ContaID)
+		datas = append(datas, s.Data.Format("2006-01-02"))
+		importacoes = append(importacoes, s.ImportacaoID)
+		var extrato sql.NullString
+		if s.SaldoExtrato != nil {
+			extrato = sql.NullString{String: s.SaldoExtrato.String(), Valid: true}
+		}
+		extratos = append(extratos, extrato)
+	}
+	return contas, datas, importacoes, extratos
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
+)
+
+func TestRestoreSaldosDiarios(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, time.January, d, 0, 0, 0, 0, time.UTC) }
+	saldo := func(c money.Centavos) *money.Centavos { return &c }
+
+	// A primeira importação cobre de 1 a 10 de janeiro; a segunda, revertida, de 5 a 15
+	primeira := []saldoImportacao{
+		{ContaID: "conta", Data: day(5), ImportacaoID: "primeira", SaldoExtrato: saldo(1000), IniciadoEm: day(11)},
+		{ContaID: "conta", Data: day(10), ImportacaoID: "primeira", SaldoExtrato: nil, IniciadoEm: day(11)},
+	}
+	segunda := []saldoImportacao{
+		{ContaID: "conta", Data: day(5), ImportacaoID: "segunda", SaldoExtrato: saldo(2000), IniciadoEm: day(16)},
+		{ContaID: "conta", Data: day(10), ImportacaoID: "segunda", SaldoExtrato: saldo(3000), IniciadoEm: day(16)},
+		{ContaID: "conta", Data: day(15), ImportacaoID: "segunda", SaldoExtrato: saldo(4000), IniciadoEm: day(16)},
+	}
+	// Uma terceira importação, posterior, gravou o dia 5 sem saldo
+	terceira := []saldoImportacao{
+		{ContaID: "conta", Data: day(5), ImportacaoID: "terceira", SaldoExtrato: nil, IniciadoEm: day(20)},
+	}
+
+	restaurados, removidos := restoreSaldosDiarios(segunda, append(append([]saldoImportacao{}, terceira...), primeira...))
+
+	tests := []struct {
+		data         time.Time
+		importacaoID string
+		saldoExtrato *money.Centavos
+	}{
+		// Última importação do dia, com o saldo da primeira, o último informado
+		{day(5), "terceira", saldo(1000)},
+		// Volta à primeira, que não informou saldo
+		{day(10), "primeira", nil},
+	}
+	if len(restaurados) != len(tests) {
+		t.Fatalf("restaurados = %+v, want %d days", restaurados, len(tests))
+	}
+	for i, tt := range tests {
+		got := restaurados[i]
+		if !got.Data.Equal(tt.data) || got.ImportacaoID != tt.importacaoID {
+			t.Errorf("restaurados[%d] = %s %s, want %s %s",
+				i, got.Data.Format("2006-01-02"), got.ImportacaoID, tt.data.Format("2006-01-02"), tt.importacaoID)
+		}
+		if (got.SaldoExtrato == nil) != (tt.saldoExtrato == nil) || (got.SaldoExtrato != nil && *got.SaldoExtrato != *tt.saldoExtrato) {
+			t.Errorf("restaurados[%d].SaldoExtrato = %v, want %v", i, got.SaldoExtrato, tt.saldoExtrato)
+		}
+	}
+
+	// Só a segunda importação cobria o dia 15
+	if len(removidos) != 1 || !removidos[0].Data.Equal(day(15)) {
+		t.Errorf("removidos = %+v, want only %s", removidos, day(15).Format("2006-01-02"))
+	}
+}
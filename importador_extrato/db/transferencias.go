@@ -0,0 +1,89 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// TransferenciaCandidata é uma transação ainda fora de uma transferência interna, com a conta,
+// a empresa titular e a contraparte
+type TransferenciaCandidata struct {
+	models.Transaction
+	ContaNome            string  `db:"conta_nome"`
+	EmpresaNome          string  `db:"empresa_nome"`
+	EmpresaCNPJ          string  `db:"empresa_cnpj"`
+	ContraparteNome      *string `db:"contraparte_nome"`
+	ContraparteDocumento *string `db:"contraparte_documento"`
+}
+
+// ListTransferenciaCandidatas retorna as transações no intervalo [from, to] que ainda não fazem
+// parte de uma transferência interna, em ordem de data
+func (db *DB) ListTransferenciaCandidatas(from, to time.Time) ([]TransferenciaCandidata, error) {
+	query := `
+SELECT t.*, c.nome AS conta_nome, e.nome AS empresa_nome, e.cnpj AS empresa_cnpj,
+       cp.nome AS contraparte_nome, cp.documento AS contraparte_documento
+FROM (SELECT ` + transacaoColumns + ` FROM financeiro.transacoes) t
+JOIN financeiro.contas c ON c.id = t.conta_id
+JOIN cadastros.empresas e ON e.id = c.empresa_id
+LEFT JOIN financeiro.contrapartes cp ON cp.id = t.contraparte_id
+WHERE t.data BETWEEN $1 AND $2
+  AND NOT EXISTS (
+    SELECT 1 FROM financeiro.transferencias_internas ti
+    WHERE ti.debito_id = t.id OR ti.credito_id = t.id
+  )
+ORDER BY t.data, t.id
+`
+	var candidatas []TransferenciaCandidata
+	if err := db.Select(&candidatas, query, from, to); err != nil {
+		return nil, fmt.Errorf("error listing transferencia candidates: %v", err)
+	}
+	return candidatas, nil
+}
+
+// NewTransferenciaInterna monta o registro que liga o débito ao crédito de uma transferência interna
+func NewTransferenciaInterna(debitoID, creditoID string) models.TransferenciaInterna {
+	return models.TransferenciaInterna{
+		ID:        uuid.Must(uuid.NewV7()).String(),
+		DebitoID:  debitoID,
+		CreditoID: creditoID,
+		CriadoEm:  time.Now(),
+	}
+}
+
+// InsertTransferenciasInternas grava as transferências em uma única transação. Transferências de
+// transações já ligadas a outra são ignoradas. Retorna o número de transferências gravadas.
+func (db *DB) InsertTransferenciasInternas(transferencias []models.TransferenciaInterna) (int, error) {
+	if len(transferencias) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, len(transferencias))
+	debitos := make([]string, len(transferencias))
+	creditos := make([]string, len(transferencias))
+	for i, t := range transferencias {
+		ids[i], debitos[i], creditos[i] = t.ID, t.DebitoID, t.CreditoID
+	}
+
+	query := `
+INSERT INTO financeiro.transferencias_internas (id, debito_id, credito_id)
+SELECT * FROM UNNEST($1::uuid[], $2::uuid[], $3::uuid[])
+ON CONFLICT DO NOTHING
+`
+	result, err := db.Exec(query, pq.Array(ids), pq.Array(debitos), pq.Array(creditos))
+	if err != nil {
+		return 0, fmt.Errorf("error inserting transferencias internas: %w", classifyError(err))
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error inserting transferencias internas: %v", err)
+	}
+
+	db.logger.Debug("transferencias internas inserted", "transferencias", len(transferencias), "inserted", inserted)
+
+	return int(inserted), nil
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/db"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/parser"
+)
+
+// Situação prevista de cada registro na simulação
+const (
+	previewNew       = "new"       // seria gravado
+	previewDuplicate = "duplicate" // já está no banco ou repetido no arquivo; seria ignorado
+	previewFail      = "fail"      // seria rejeitado pelo banco
+	previewParsed    = "parsed"    // lido sem consultar o banco (modo offline)
+)
+
+// filePreview é o resultado da simulação da importação de um arquivo
+type filePreview struct {
+	File        string          `json:"file"`
+	Parser      string          `json:"parser,omitempty"`
+	Confidence  *float64        `json:"confidence,omitempty"`
+	Account     string          `json:"account,omitempty"`
+	Period      string          `json:"period,omitempty"`
+	ContaID     string          `json:"conta_id,omitempty"`
+	EmpresaID   string          `json:"empresa_id,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	Diagnostics []rowDiagnostic `json:"diagnostics"`
+	RolledBack  bool            `json:"would_roll_back"` // sem best effort, qualquer falha desfaz o arquivo inteiro
+	Das         *dasPreview     `json:"das,omitempty"`
+	Rows        []rowPreview    `json:"rows"`
+	Counts      previewCounts   `json:"counts"`
+}
+
+// dasPreview é o documento DAS lido do arquivo e sua situação prevista
+type dasPreview struct {
+	CNPJ            string         `json:"cnpj"`
+	PeriodoApuracao string         `json:"periodo_apuracao"`
+	DataVencimento  string         `json:"data_vencimento"`
+	NumeroDocumento string         `json:"numero_documento"`
+	ValorTotal      money.Centavos `json:"valor_total"`
+	Status          string         `json:"status"`
+	Reason          string         `json:"reason,omitempty"`
+}
+
+// rowPreview é uma transação do extrato e sua situação prevista
+type rowPreview struct {
+	Index       int            `json:"index"`
+	Date        string         `json:"date"`
+	Description string         `json:"description"`
+	Details     string         `json:"details,omitempty"`
+	Amount      money.Centavos `json:"amount"`
+	BankID      string         `json:"bank_id,omitempty"`
+	Fingerprint string         `json:"fingerprint,omitempty"`
+	Status      string         `json:"status"`
+	Reason      string         `json:"reason,omitempty"`
+
+	date time.Time
+}
+
+// previewCounts totaliza as situações previstas
+type previewCounts struct {
+	New       int `json:"new"`
+	Duplicate int `json:"duplicate"`
+	Fail      int `json:"fail"`
+	Parsed    int `json:"parsed"`
+}
+
+func (c *previewCounts) add(status string) {
+	switch status {
+	case previewNew:
+		c.New++
+	case previewDuplicate:
+		c.Duplicate++
+	case previewFail:
+		c.Fail++
+	case previewParsed:
+		c.Parsed++
+	}
+}
+
+// dryRunFiles simula a importação dos arquivos sem gravar nada. Com o banco disponível
+// resolve contas e empresas e compara os fingerprints com os já gravados (apenas consultas);
+// sem banco mostra só o que foi lido dos arquivos.
+func dryRunFiles(factory *parser.ParserFactory, files []string, opts importOptions, repo db.Repository, format string) int {
+	previews := make([]*filePreview, len(files))
+	var totals previewCounts
+	var failedFiles, rolledBack int
+
+	for i, filePath := range files {
+		preview := previewFile(factory, repo, filePath, opts)
+		previews[i] = preview
+
+		if preview.Error != "" {
+			failedFiles++
+		}
+		if preview.RolledBack {
+			rolledBack++
+		}
+		totals.New += preview.Counts.New
+		totals.Duplicate += preview.Counts.Duplicate
+		totals.Fail += preview.Counts.Fail
+		totals.Parsed += preview.Counts.Parsed
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err := enc.Encode(struct {
+			Offline bool           `json:"offline"`
+			Files   []*filePreview `json:"files"`
+			Totals  previewCounts  `json:"totals"`
+		}{repo == nil, previews, totals})
+		if err != nil {
+			logger.Error("error writing JSON", "error", err)
+			return exitFailure
+		}
+	} else {
+		for _, preview := range previews {
+			printFilePreview(preview)
+		}
+
+		fmt.Printf("\n=== Dry-run Summary (nothing was written) ===\n")
+		fmt.Printf("Files read: %d\n", len(files))
+		fmt.Printf("Files with errors: %d\n", failedFiles)
+		fmt.Printf("Files that would be rolled back: %d\n", rolledBack)
+		if repo == nil {
+			fmt.Printf("Transactions parsed: %d (database not checked)\n", totals.Parsed)
+		} else {
+			fmt.Printf("Would be imported: %d\n", totals.New)
+			fmt.Printf("Would be skipped (duplicates): %d\n", totals.Duplicate)
+			fmt.Printf("Would fail: %d\n", totals.Fail)
+		}
+	}
+
+	if failedFiles > 0 || totals.Fail > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// previewFile lê o arquivo e prevê a situação de cada registro
+func previewFile(factory *parser.ParserFactory, repo db.Repository, filePath string, opts importOptions) *filePreview {
+	preview := &filePreview{File: filePath, Diagnostics: []rowDiagnostic{}, Rows: []rowPreview{}}
+
+	parsed, err := parseFile(factory, filePath, opts)
+	if parsed != nil {
+		preview.Parser = parsed.Parser.GetName()
+		if parsed.Detection != nil {
+			preview.Confidence = &parsed.Detection.Confidence
+		}
+	}
+	if parsed != nil && parsed.Statement != nil {
+		preview.Diagnostics = newRowDiagnostics(parsed.Statement)
+	}
+	if err != nil {
+		preview.Error = err.Error()
+		preview.RolledBack = true
+		return preview
+	}
+
+	stmt := parsed.Statement
+	preview.Account = stmt.AccountNumber
+	preview.Period = stmt.Period
+
+	// Mesmos casos de importStatement: as guias do DAS não são importadas, só o extrato do Simples Nacional
+	switch stmt.AccountNumber {
+	case "das-simples-nacional":
+		return preview
+	case "extrato-simples-nacional":
+		preview.Das = previewDas(repo, preview, stmt.DasDocumento)
+		preview.Counts.add(preview.Das.Status)
+		preview.RolledBack = preview.Error != "" || (preview.Counts.Fail > 0 && !opts.bestEffort)
+		return preview
+	}
+
+	var contaErr error
+	if repo != nil {
+		preview.ContaID, contaErr = repo.GetContaIDByNumero(stmt.AccountNumber)
+		if contaErr != nil {
+			preview.Error = fmt.Sprintf("error finding conta ID for %s: %v", stmt.AccountNumber, contaErr)
+		} else if err := checkParserOverlap(repo, preview.ContaID, preview.Parser, stmt); err != nil {
+			preview.Error = err.Error()
+		}
+	}
+
+	// Sem banco o fingerprint não pode ser calculado, pois depende do ID da conta
+	transacoes := newTransactions("", preview.ContaID, stmt)
+
+	var existing map[string]bool
+	if repo != nil && contaErr == nil {
+		fingerprints := make([]string, len(transacoes))
+		for i, t := range transacoes {
+			fingerprints[i] = t.Fingerprint
+		}
+		existing, err = repo.ExistingFingerprints(preview.ContaID, fingerprints)
+		if err != nil {
+			preview.Error = err.Error()
+		}
+	}
+
+	seen := map[string]bool{}
+	for i, t := range stmt.Transactions {
+		row := rowPreview{
+			Index:       i + 1,
+			date:        t.Date,
+			Date:        t.Date.Format("2006-01-02"),
+			Description: t.Description,
+			Details:     t.Details,
+			Amount:      t.Amount,
+			BankID:      t.ID,
+		}
+
+		switch {
+		case repo == nil:
+			row.Status = previewParsed
+		case preview.Error != "":
+			row.Status = previewFail
+			row.Reason = preview.Error
+		default:
+			transacao := transacoes[i]
+			row.Fingerprint = transacao.Fingerprint
+
+			if err := db.CheckTransaction(transacao); err != nil {
+				row.Status = previewFail
+				row.Reason = err.Error()
+			} else if existing[transacao.Fingerprint] {
+				row.Status = previewDuplicate
+				row.Reason = "already in database"
+			} else if seen[transacao.Fingerprint] {
+				row.Status = previewDuplicate
+				row.Reason = "repeated in file"
+			} else {
+				row.Status = previewNew
+			}
+			seen[transacao.Fingerprint] = true
+		}
+
+		preview.Rows = append(preview.Rows, row)
+		preview.Counts.add(row.Status)
+	}
+
+	preview.RolledBack = preview.Error != "" || (preview.Counts.Fail > 0 && !opts.bestEffort)
+
+	return preview
+}
+
+// previewDas prevê a situação do documento DAS, seguindo as restrições únicas de financeiro.das_documentos
+func previewDas(repo db.Repository, preview *filePreview, doc *parser.DasDocumento) *dasPreview {
+	das := &dasPreview{
+		CNPJ:            doc.CNPJ,
+		PeriodoApuracao: doc.PeriodoApuracao.Format("2006-01"),
+		DataVencimento:  doc.DataVencimento.Format("2006-01-02"),
+		NumeroDocumento: doc.NumeroDocumento,
+		ValorTotal:      doc.ValorTotal,
+		Status:          previewParsed,
+	}
+
+	if repo == nil {
+		return das
+	}
+
+	empresaID, err := repo.GetEmpresaIDByCNPJ(doc.CNPJ)
+	if err != nil {
+		preview.Error = fmt.Sprintf("error finding empresa ID for %s: %v", doc.CNPJ, err)
+		das.Status, das.Reason = previewFail, preview.Error
+		return das
+	}
+	preview.EmpresaID = empresaID
+
+	numeroExists, periodoExists, err := repo.DasDocumentoConflicts(empresaID, doc.PeriodoApuracao, doc.NumeroDocumento)
+	switch {
+	case err != nil:
+		preview.Error = err.Error()
+		das.Status, das.Reason = previewFail, preview.Error
+	case numeroExists:
+		das.Status, das.Reason = previewDuplicate, "already in database"
+	case periodoExists:
+		das.Status, das.Reason = previewDuplicate, "empresa already has another das documento for this period"
+	default:
+		das.Status = previewNew
+	}
+
+	return das
+}
+
+// printFilePreview mostra a simulação de um arquivo em formato de tabela
+func printFilePreview(preview *filePreview) {
+	fmt.Printf("\n=== %s ===\n", preview.File)
+	if preview.Parser != "" {
+		parserDesc := preview.Parser
+		if preview.Confidence != nil {
+			parserDesc = fmt.Sprintf("%s (confidence %.0f%%)", preview.Parser, *preview.Confidence*100)
+		}
+		fmt.Printf("Parser: %s\n", parserDesc)
+	}
+	if preview.Account != "" {
+		fmt.Printf("Account: %s\n", preview.Account)
+	}
+	if preview.Period != "" {
+		fmt.Printf("Period: %s\n", preview.Period)
+	}
+	if preview.Error != "" {
+		fmt.Printf("Error: %s\n", preview.Error)
+	}
+	for _, d := range preview.Diagnostics {
+		fmt.Printf("Diagnostic (%s, line %d): %s\n", d.Severity, d.Line, d.Reason)
+		if d.Record != "" {
+			fmt.Printf("  %s\n", d.Record)
+		}
+	}
+	if preview.RolledBack {
+		fmt.Println("Nothing from this file would be imported (atomic mode rolls back the whole file)")
+	}
+
+	if das := preview.Das; das != nil {
+		fmt.Printf("DAS: CNPJ %s, período %s, vencimento %s, número %s, valor %s\n",
+			das.CNPJ, das.PeriodoApuracao, das.DataVencimento, das.NumeroDocumento, das.ValorTotal.FormatBR())
+		fmt.Printf("Status: %s %s\n", das.Status, das.Reason)
+		return
+	}
+
+	if len(preview.Rows) == 0 {
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tDATA\tVALOR\tDESCRIÇÃO\tSITUAÇÃO\tMOTIVO")
+	for _, row := range preview.Rows {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			row.Index,
+			row.date.Format("02/01/2006"),
+			row.Amount.FormatBR(),
+			truncate(row.Description, 50),
+			row.Status,
+			row.Reason,
+		)
+	}
+	w.Flush()
+
+	c := preview.Counts
+	if c.Parsed > 0 {
+		fmt.Printf("Transactions: %d\n", c.Parsed)
+	} else {
+		fmt.Printf("New: %d, duplicates: %d, failures: %d\n", c.New, c.Duplicate, c.Fail)
+	}
+}
+
+// truncate limita o texto a n caracteres
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
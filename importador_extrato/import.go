@@ -0,0 +1,790 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/config"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/db"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/parser"
+)
+
+// importOptions são as opções do comando import
+type importOptions struct {
+	parserName string // força o parser pelo nome, sem detecção pelo conteúdo
+	account    string // sobrescreve o número da conta informado no extrato
+	bestEffort bool
+	dryRun     bool
+	strict     bool // qualquer diagnóstico do parser (warning ou error) invalida o arquivo
+}
+
+// stringList é uma flag que pode ser repetida
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// runImport importa os arquivos informados ou todos os arquivos suportados do diretório.
+// O período de uma conta deve ser importado sempre no mesmo formato (CSV ou OFX, por exemplo):
+// extratos que cobrem transações já gravadas por outro parser são recusados (veja checkParserOverlap).
+func runImport(args []string) int {
+	var files stringList
+	var opts importOptions
+
+	fs := newFlagSet("import", "")
+	dir := fs.String("dir", "./rawdata/extrato", "diretório com os arquivos a importar (busca recursiva)")
+	fs.Var(&files, "file", "arquivo a importar; pode ser repetido e ignora -dir")
+	fs.StringVar(&opts.parserName, "parser", "", "nome do parser a usar, sem detecção pelo conteúdo (veja o comando parsers)")
+	fs.StringVar(&opts.account, "account", "", "número da conta a usar no lugar do informado no extrato")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "simula a importação, mostrando o que seria gravado, ignorado ou rejeitado, sem gravar no banco de dados")
+	format := fs.String("format", "table", "formato da saída do -dry-run: table ou json")
+	offline := fs.Bool("offline", false, "com -dry-run, apenas lê os arquivos, sem consultar o banco de dados")
+	reportFormat := fs.String("report", "text", "formato do relatório da importação na saída padrão: text ou json")
+	reportFile := fs.String("report-file", "", "grava também o relatório da importação em JSON neste arquivo")
+	fs.BoolVar(&opts.strict, "strict", false, "rejeita o arquivo inteiro se o parser encontrar qualquer linha com problema")
+	bestEffort := fs.Bool("best-effort", false, "grava as linhas válidas mesmo que outras falhem (padrão: variável IMPORT_BEST_EFFORT)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	if *format != "table" && *format != "json" {
+		logger.Error("invalid format (use table or json)", "format", *format)
+		return exitUsage
+	}
+	if *reportFormat != "text" && *reportFormat != "json" {
+		logger.Error("invalid report format (use text or json)", "report", *reportFormat)
+		return exitUsage
+	}
+
+	// Create parser factory
+	factory := parser.NewParserFactory(logger)
+
+	if opts.parserName != "" {
+		if _, err := factory.GetParserByName(opts.parserName); err != nil {
+			logger.Error("invalid parser", "error", err)
+			return exitUsage
+		}
+	}
+
+	// Em JSON a saída padrão traz apenas o documento
+	jsonOutput := (opts.dryRun && *format == "json") || (!opts.dryRun && *reportFormat == "json")
+	if !jsonOutput {
+		fmt.Println("=== Importador de Extratos ===")
+		fmt.Printf("Parsers disponíveis: %s\n\n", strings.Join(factory.ListSupportedParsers(), ", "))
+	}
+
+	if len(files) == 0 {
+		// List all supported files in the directory
+		found, err := findImportableFiles(*dir)
+		if err != nil {
+			logger.Error("error listing files", "dir", *dir, "error", err)
+			return exitFailure
+		}
+
+		if len(found) == 0 {
+			logger.Error("no importable files found", "dir", *dir)
+			return exitFailure
+		}
+		files = found
+	}
+
+	// A flag, quando informada, tem precedência sobre a variável de ambiente
+	resolveBestEffort := func(cfg *config.Config) {
+		if cfg != nil {
+			opts.bestEffort = cfg.ImportBestEffort
+		}
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == "best-effort" {
+				opts.bestEffort = *bestEffort
+			}
+		})
+	}
+
+	if opts.dryRun {
+		// Sem banco o repositório fica nil e os arquivos são apenas lidos
+		var repo db.Repository
+		var cfg *config.Config
+		if !*offline {
+			var database *db.DB
+			var err error
+			cfg, database, err = connect()
+			if err != nil {
+				logger.Warn("database unavailable - dry run will only read the files", "error", err)
+			} else {
+				defer database.Close()
+				repo = database
+			}
+		}
+		resolveBestEffort(cfg)
+		return dryRunFiles(factory, files, opts, repo, *format)
+	}
+
+	cfg, database, err := connect()
+	if err != nil {
+		logger.Error("database unavailable", "error", err)
+		return exitConfig
+	}
+	defer database.Close()
+	resolveBestEffort(cfg)
+
+	report := importFiles(database, factory, files, opts)
+
+	code := exitOK
+	if report.failed() {
+		code = exitFailure
+	}
+
+	if *reportFile != "" {
+		if err := report.writeJSONFile(*reportFile); err != nil {
+			logger.Error("error writing report file", "path", *reportFile, "error", err)
+			code = exitFailure
+		}
+	}
+
+	if *reportFormat == "json" {
+		if err := report.writeJSON(os.Stdout); err != nil {
+			logger.Error("error writing report", "error", err)
+			code = exitFailure
+		}
+	} else {
+		report.printSummary(os.Stdout)
+	}
+
+	return code
+}
+
+// importFiles importa os arquivos, cada um na sua transação, e retorna o relatório da execução
+func importFiles(repo db.Repository, factory *parser.ParserFactory, files []string, opts importOptions) *importReport {
+	mode := "atomic"
+	if opts.bestEffort {
+		mode = "best effort"
+	}
+	logger.Info("starting import", "files", len(files), "mode", mode)
+
+	// Process each file
+	report := &importReport{StartedAt: time.Now(), BestEffort: opts.bestEffort, Files: []*fileReport{}}
+
+	for fileIndex, filePath := range files {
+		flog := logger.With("file", filepath.Base(filePath))
+		flog.Info("processing file", "index", fileIndex+1, "total", len(files))
+
+		rep := &fileReport{Path: filePath, Status: fileCommitted, Diagnostics: []rowDiagnostic{}, RowErrors: []rowError{}}
+		imp, err := importFile(repo, factory, filePath, opts, rep, flog)
+		report.add(rep)
+		if err != nil {
+			flog.Error("file rolled back", "error", err)
+			continue
+		}
+
+		flog.Info("file committed",
+			"importacao_id", imp.ID,
+			"imported", imp.QtdImportadas,
+			"skipped", imp.QtdIgnoradas,
+			"failed", imp.QtdFalhas,
+			"diagnostics", len(rep.Diagnostics))
+	}
+
+	report.FinishedAt = time.Now()
+	return report
+}
+
+// importFile processa um arquivo dentro de uma transação do banco e registra a importação
+// em financeiro.importacoes, inclusive quando o arquivo não pôde ser importado.
+// Em caso de erro a importação retornada traz as contagens até a falha.
+// O resultado do arquivo é registrado também em rep.
+func importFile(repo db.Repository, factory *parser.ParserFactory, filePath string, opts importOptions, rep *fileReport, flog *slog.Logger) (*models.Importacao, error) {
+	imp, err := startImportacao(repo, filePath)
+	if err != nil {
+		rep.Status, rep.Error = fileRolledBack, err.Error()
+		return nil, err
+	}
+	rep.ImportacaoID = imp.ID
+
+	importErr := importInTransaction(repo, factory, filePath, imp, opts, rep, flog.With("importacao_id", imp.ID))
+
+	imp.Status = models.ImportacaoConcluida
+	if importErr != nil {
+		// Nada do arquivo foi gravado
+		imp.Status = models.ImportacaoFalha
+		imp.QtdImportadas = 0
+		msg := importErr.Error()
+		imp.MensagemErro = &msg
+	}
+
+	if err := repo.FinishImportacao(imp); err != nil {
+		flog.Error("error finishing importacao", "importacao_id", imp.ID, "error", err)
+	}
+
+	counts := recordCounts{Imported: imp.QtdImportadas, Skipped: imp.QtdIgnoradas, Failed: imp.QtdFalhas}
+	switch {
+	case rep.Das != nil:
+		*rep.Das = counts
+	case rep.Transactions != nil:
+		*rep.Transactions = counts
+	}
+	if importErr != nil {
+		rep.Status, rep.Error = fileRolledBack, importErr.Error()
+	}
+
+	return imp, importErr
+}
+
+// startImportacao registra o início da importação do arquivo
+func startImportacao(repo db.Repository, filePath string) (*models.Importacao, error) {
+	hash, err := hashFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return repo.StartImportacao(filepath.Base(filePath), hash)
+}
+
+// importInTransaction grava o arquivo em uma única transação, que só é confirmada se a importação terminar sem erro
+func importInTransaction(repo db.Repository, factory *parser.ParserFactory, filePath string, imp *models.Importacao, opts importOptions, rep *fileReport, flog *slog.Logger) error {
+	tx, err := repo.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := importStatement(repo, tx, factory, filePath, imp, opts, rep, flog); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// importStatement detecta o parser, lê o arquivo e grava as transações ou o documento DAS.
+// Sem opts.bestEffort, o primeiro registro rejeitado interrompe o arquivo.
+func importStatement(repo db.Repository, tx db.Tx, factory *parser.ParserFactory, filePath string, imp *models.Importacao, opts importOptions, rep *fileReport, flog *slog.Logger) error {
+	parsed, err := parseFile(factory, filePath, opts)
+	if parsed != nil {
+		parserName := parsed.Parser.GetName()
+		imp.Parser = &parserName
+		rep.Parser = parserName
+		flog = flog.With("parser", parserName)
+		flog.Info("using parser", "detection", parsed.describeDetection())
+	}
+	if parsed != nil && parsed.Statement != nil {
+		// Diagnósticos do parser vão para o relatório e para o log, mesmo que o arquivo seja rejeitado
+		rep.Diagnostics = newRowDiagnostics(parsed.Statement)
+		logDiagnostics(flog, parsed.Statement)
+	}
+	if err != nil {
+		return err
+	}
+	stmt := parsed.Statement
+	rep.Account = stmt.AccountNumber
+	rep.Period = stmt.Period
+	flog = flog.With("account", stmt.AccountNumber)
+
+	switch stmt.AccountNumber {
+	case "das-simples-nacional":
+	case "extrato-simples-nacional":
+		rep.Das = &recordCounts{}
+
+		// Get empresa ID from database
+		empresaID, err := repo.GetEmpresaIDByCNPJ(stmt.DasDocumento.CNPJ)
+		if err != nil {
+			return fmt.Errorf("error finding empresa ID for %s: %v", stmt.AccountNumber, err)
+		}
+		imp.EmpresaID = &empresaID
+
+		// Import das ducumento
+		flog.Info("importing das documento", "empresa_id", empresaID, "numero_documento", stmt.DasDocumento.NumeroDocumento)
+
+		var inserted bool
+		err = tx.Savepoint(func() error {
+			var err error
+			inserted, err = tx.InsertDasDocumento(
+				imp.ID,
+				empresaID,
+				stmt.DasDocumento.PeriodoApuracao,
+				stmt.DasDocumento.DataVencimento,
+				stmt.DasDocumento.NumeroDocumento,
+				stmt.DasDocumento.ValorTotal,
+			)
+			return err
+		})
+
+		if err != nil {
+			// Outro documento da empresa no mesmo período, ou o mesmo número em outra empresa
+			if errors.Is(err, db.ErrDuplicate) {
+				flog.Info("das documento already imported - skipping", "constraint", db.ConstraintName(err))
+				imp.QtdIgnoradas++
+				return nil
+			}
+			imp.QtdFalhas++
+			rep.RowErrors = append(rep.RowErrors, rowError{
+				Date:        stmt.DasDocumento.PeriodoApuracao.Format("2006-01-02"),
+				Description: "DAS " + stmt.DasDocumento.NumeroDocumento,
+				Constraint:  db.ConstraintName(err),
+				Error:       err.Error(),
+			})
+			if !opts.bestEffort {
+				return fmt.Errorf("error inserting das documento: %v", err)
+			}
+			flog.Warn("error inserting das documento - skipping", "error", err)
+			return nil
+		}
+
+		if !inserted {
+			imp.QtdIgnoradas++
+			return nil
+		}
+		imp.QtdImportadas++
+
+	default:
+		rep.Transactions = &recordCounts{}
+
+		// Get conta ID from database
+		contaID, err := repo.GetContaIDByNumero(stmt.AccountNumber)
+		if err != nil {
+			return fmt.Errorf("error finding conta ID for %s: %v", stmt.AccountNumber, err)
+		}
+		imp.ContaID = &contaID
+
+		if err := checkParserOverlap(repo, contaID, parsed.Parser.GetName(), stmt); err != nil {
+			return err
+		}
+
+		// Import transactions
+		flog.Info("importing transactions", "conta_id", contaID, "transactions", len(stmt.Transactions))
+
+		transacoes := newTransactions(imp.ID, contaID, stmt)
+		if err := categorizeTransactions(repo, contaID, transacoes, flog); err != nil {
+			return err
+		}
+		if err := linkContrapartes(tx, transacoes, stmt.Transactions, opts.bestEffort, flog); err != nil {
+			return err
+		}
+
+		if err := insertTransactions(tx, transacoes, stmt.Transactions, imp, rep, opts.bestEffort, flog); err != nil {
+			return err
+		}
+
+		return saveSaldosDiarios(tx, contaID, imp.ID, stmt, rep, opts.bestEffort, flog)
+	}
+
+	return nil
+}
+
+// saveSaldosDiarios grava o saldo de fim de dia dos dias do extrato: o saldo informado pelo banco,
+// quando houver, e o calculado a partir das transações. Dias em que os dois diferem são registrados
+// como divergentes no banco e no relatório.
+func saveSaldosDiarios(tx db.Tx, contaID, importacaoID string, stmt *parser.Statement, rep *fileReport, bestEffort bool, flog *slog.Logger) error {
+	days := map[time.Time]*db.SaldoDiarioInput{}
+	for _, t := range stmt.Transactions {
+		if days[t.Date] == nil {
+			days[t.Date] = &db.SaldoDiarioInput{Data: t.Date}
+		}
+	}
+	for _, b := range stmt.DailyBalances {
+		balance := b.Balance
+		days[b.Date] = &db.SaldoDiarioInput{Data: b.Date, SaldoExtrato: &balance}
+	}
+
+	inputs := make([]db.SaldoDiarioInput, 0, len(days))
+	for _, in := range days {
+		inputs = append(inputs, *in)
+	}
+	slices.SortFunc(inputs, func(a, b db.SaldoDiarioInput) int { return a.Data.Compare(b.Data) })
+
+	var saved []models.SaldoDiario
+	err := tx.Savepoint(func() error {
+		var err error
+		saved, err = tx.UpsertSaldosDiarios(contaID, importacaoID, inputs)
+		return err
+	})
+	if err != nil {
+		if !bestEffort {
+			return err
+		}
+		flog.Warn("error saving saldos diarios - skipping", "error", err)
+		return nil
+	}
+
+	rep.Balances = &balanceCounts{Days: len(saved)}
+	for _, s := range saved {
+		if s.Divergente {
+			rep.Balances.Divergent++
+			flog.Warn("daily balance differs from the computed balance",
+				"date", s.Data.Format("2006-01-02"),
+				"statement", s.SaldoExtrato,
+				"computed", s.SaldoCalculado)
+		}
+	}
+
+	return nil
+}
+
+// insertTransactions grava as transações do arquivo em lote. Transações que o banco rejeitaria
+// são identificadas antes, com a linha do arquivo. Em modo best effort elas são descartadas e,
+// se ainda assim o lote for rejeitado, grava linha a linha descartando só as linhas inválidas.
+// source são as transações do extrato, na mesma ordem de transacoes.
+func insertTransactions(tx db.Tx, transacoes []*models.Transaction, source []parser.Transaction, imp *models.Importacao, rep *fileReport, bestEffort bool, flog *slog.Logger) error {
+	var valid []*models.Transaction
+	var validSource []parser.Transaction
+	for i, t := range transacoes {
+		if err := db.CheckTransaction(t); err != nil {
+			flog.Warn("invalid transaction", "line", source[i].Line, "titulo", t.Titulo, "error", err)
+			rep.addRowError(source[i], err)
+			imp.QtdFalhas++
+			continue
+		}
+		valid = append(valid, t)
+		validSource = append(validSource, source[i])
+	}
+
+	if imp.QtdFalhas > 0 && !bestEffort {
+		return fmt.Errorf("%d transaction(s) would be rejected by the database (first at line %d: %s)",
+			imp.QtdFalhas, rep.RowErrors[0].Line, rep.RowErrors[0].Error)
+	}
+
+	var result *db.InsertResult
+	err := tx.Savepoint(func() error {
+		var err error
+		result, err = tx.InsertTransactions(valid)
+		return err
+	})
+
+	if err == nil {
+		imp.QtdImportadas += result.Inserted
+		imp.QtdIgnoradas += result.Duplicates
+		return nil
+	}
+
+	if !bestEffort {
+		imp.QtdFalhas += len(valid)
+		return err
+	}
+
+	flog.Warn("batch insert failed - retrying row by row", "error", err)
+	for i, t := range valid {
+		var inserted bool
+		err := tx.Savepoint(func() error {
+			var err error
+			inserted, err = tx.InsertTransaction(t)
+			return err
+		})
+
+		if errors.Is(err, db.ErrDuplicate) {
+			flog.Debug("transaction already imported - skipping", "line", validSource[i].Line, "constraint", db.ConstraintName(err))
+			imp.QtdIgnoradas++
+			continue
+		}
+
+		if err != nil {
+			flog.Warn("error inserting transaction - skipping", "line", validSource[i].Line, "titulo", t.Titulo, "error", err)
+			rep.addRowError(validSource[i], err)
+			imp.QtdFalhas++
+			continue
+		}
+
+		if !inserted {
+			imp.QtdIgnoradas++
+			continue
+		}
+
+		imp.QtdImportadas++
+	}
+
+	return nil
+}
+
+// newTransactions monta os registros das transações do extrato para a conta
+func newTransactions(importacaoID, contaID string, stmt *parser.Statement) []*models.Transaction {
+	inputs := make([]db.TransactionInput, len(stmt.Transactions))
+	for i, t := range stmt.Transactions {
+		inputs[i] = db.TransactionInput{
+			BankID:      t.ID,
+			Date:        t.Date,
+			Description: t.Description,
+			Details:     t.Details,
+			Amount:      t.Amount,
+		}
+	}
+	return db.NewTransactions(importacaoID, contaID, inputs)
+}
+
+// checkParserOverlap recusa o extrato se a conta já tiver transações no mesmo período gravadas por
+// outro parser. Os fingerprints dependem do formato: o CSV do Inter não traz identificador e usa
+// os dados da transação, o OFX usa o FITID. Importar o mesmo período nos dois formatos gravaria
+// cada transação duas vezes; o período deve ser importado sempre no mesmo formato.
+func checkParserOverlap(repo db.Repository, contaID, parserName string, stmt *parser.Statement) error {
+	from, to, ok := statementPeriod(stmt)
+	if !ok {
+		return nil
+	}
+
+	parsers, err := repo.ImportacaoParsers(contaID, from, to)
+	if err != nil {
+		return err
+	}
+	for _, p := range parsers {
+		if p != parserName {
+			return fmt.Errorf("conta %s already has transactions between %s and %s imported with parser %s; import this period in the same format to avoid duplicates",
+				stmt.AccountNumber, from.Format("2006-01-02"), to.Format("2006-01-02"), p)
+		}
+	}
+	return nil
+}
+
+// statementPeriod retorna o período do extrato: o informado pelo banco ("01/01/2025 a 31/01/2025"),
+// estendido até as datas das transações
+func statementPeriod(stmt *parser.Statement) (from, to time.Time, ok bool) {
+	if start, end, found := strings.Cut(stmt.Period, " a "); found {
+		start, errStart := time.Parse("02/01/2006", strings.TrimSpace(start))
+		end, errEnd := time.Parse("02/01/2006", strings.TrimSpace(end))
+		if errStart == nil && errEnd == nil {
+			from, to, ok = start, end, true
+		}
+	}
+	for _, t := range stmt.Transactions {
+		if !ok || t.Date.Before(from) {
+			from = t.Date
+		}
+		if !ok || t.Date.After(to) {
+			to = t.Date
+		}
+		ok = true
+	}
+	return from, to, ok
+}
+
+// linkContrapartes grava as contrapartes identificadas pelo parser e as vincula às transações.
+// source são as transações do extrato, na mesma ordem de transacoes. Em modo best effort, uma
+// falha ao gravar as contrapartes não impede a importação das transações, que ficam sem vínculo.
+func linkContrapartes(tx db.Tx, transacoes []*models.Transaction, source []parser.Transaction, bestEffort bool, flog *slog.Logger) error {
+	contrapartes := make([]*models.Contraparte, len(transacoes))
+	var found int
+	for i, t := range source {
+		c := t.Counterparty
+		if c == nil || c.Name == "" {
+			continue
+		}
+		contrapartes[i] = db.NewContraparte(db.ContraparteInput{
+			Nome:        c.Name,
+			Documento:   c.Document,
+			BancoNome:   c.BankName,
+			BancoCodigo: c.BankCode,
+			ISPB:        c.ISPB,
+			Agencia:     c.Agency,
+			Conta:       c.Account,
+		})
+		found++
+	}
+	if found == 0 {
+		return nil
+	}
+
+	err := tx.Savepoint(func() error {
+		return tx.UpsertContrapartes(contrapartes)
+	})
+	if err != nil {
+		if !bestEffort {
+			return err
+		}
+		flog.Warn("error saving contrapartes - transactions imported without them", "error", err)
+		return nil
+	}
+
+	for i, c := range contrapartes {
+		if c != nil {
+			id := c.ID
+			transacoes[i].ContraparteID = &id
+		}
+	}
+	flog.Debug("contrapartes linked", "transactions", found)
+
+	return nil
+}
+
+// parsedFile é um arquivo lido e o parser usado para lê-lo
+type parsedFile struct {
+	Parser    parser.Parser
+	Detection *parser.Detection // nil quando o parser foi informado com -parser
+	Statement *parser.Statement
+}
+
+// describeDetection descreve por que o parser foi escolhido
+func (f *parsedFile) describeDetection() string {
+	if f.Detection == nil {
+		return "forced"
+	}
+	return fmt.Sprintf("confidence %.0f%%: %s", f.Detection.Confidence*100, strings.Join(f.Detection.Evidence, "; "))
+}
+
+// parseFile abre o arquivo em disco e o lê com parseInput
+func parseFile(factory *parser.ParserFactory, filePath string, opts importOptions) (*parsedFile, error) {
+	in, err := parser.OpenInput(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	return parseInput(factory, in, opts)
+}
+
+// parseInput escolhe o parser (pelo nome informado ou pelo conteúdo) e lê o Input.
+// Se o conteúdo não puder ser lido depois de escolhido o parser, retorna o parser junto com o erro.
+func parseInput(factory *parser.ParserFactory, in *parser.Input, opts importOptions) (*parsedFile, error) {
+	parsed := &parsedFile{}
+
+	if opts.parserName != "" {
+		forced, err := factory.GetParserByName(opts.parserName)
+		if err != nil {
+			return nil, err
+		}
+		parsed.Parser = forced
+	} else {
+		// Get appropriate parser for this file
+		match, err := factory.DetectInput(in)
+		if err != nil {
+			return nil, err
+		}
+		parsed.Parser = match.Parser
+		parsed.Detection = &match.Detection
+	}
+
+	// Parse file
+	stmt, err := parsed.Parser.Parse(in)
+	if err != nil {
+		return parsed, fmt.Errorf("error parsing file: %v", err)
+	}
+
+	// Documentos DAS não pertencem a uma conta bancária
+	if opts.account != "" && stmt.DasDocumento == nil {
+		stmt.AccountNumber = opts.account
+	}
+
+	// Validate account number
+	if strings.TrimSpace(stmt.AccountNumber) == "" {
+		return parsed, fmt.Errorf("account number must be informed in the file")
+	}
+
+	parsed.Statement = stmt
+
+	if opts.strict {
+		if err := stmt.StrictError(); err != nil {
+			return parsed, err
+		}
+	}
+
+	return parsed, nil
+}
+
+// logDiagnostics envia os diagnósticos do parser ao log, no nível correspondente à severidade
+func logDiagnostics(flog *slog.Logger, stmt *parser.Statement) {
+	for _, d := range stmt.Diagnostics {
+		level := slog.LevelWarn
+		switch d.Severity {
+		case parser.SeverityInfo:
+			level = slog.LevelInfo
+		case parser.SeverityError:
+			level = slog.LevelError
+		}
+		flog.Log(context.Background(), level, "parser diagnostic", "line", d.Line, "reason", d.Reason, "record", d.Record)
+	}
+}
+
+// hashFile calcula o SHA-256 do conteúdo do arquivo
+func hashFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("error opening file: %v", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("error hashing file: %v", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// findImportableFiles busca recursivamente por arquivos suportados
+func findImportableFiles(rootDir string) ([]string, error) {
+	var files []string
+
+	// Busca por CSVs (Inter e Nubank)
+	csvFiles, err := filepath.Glob(filepath.Join(rootDir, "**/*.csv"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing CSV files: %v", err)
+	}
+	files = append(files, csvFiles...)
+
+	// Busca por OFXs (Inter e Nubank)
+	ofxFiles, err := filepath.Glob(filepath.Join(rootDir, "**/*.ofx"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing OFX files: %v", err)
+	}
+	files = append(files, ofxFiles...)
+
+	// Busca por PDFs (Simples Nacional)
+	pdfFiles, err := filepath.Glob(filepath.Join(rootDir, "**/*.pdf"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing PDF files: %v", err)
+	}
+	files = append(files, pdfFiles...)
+
+	// Como glob com ** não funciona sempre, vamos fazer busca manual
+	if len(files) == 0 {
+		files, err = findFilesRecursive(rootDir, []string{".csv", ".ofx", ".pdf"})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// findFilesRecursive busca arquivos recursivamente
+func findFilesRecursive(rootDir string, extensions []string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(path))
+		for _, validExt := range extensions {
+			if ext == validExt {
+				files = append(files, path)
+				break
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error walking directory: %v", err)
+	}
+
+	return files, nil
+}
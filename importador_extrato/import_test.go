@@ -0,0 +1,429 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/db"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/parser"
+)
+
+// Fixtures dos parsers usados nos testes de ponta a ponta
+const (
+	interFixture          = "parser/testdata/inter/extrato_jan2025.csv"
+	interInvalidFixture   = "parser/testdata/inter/linhas_invalidas.csv"
+	interDivergentFixture = "parser/testdata/inter/saldo_divergente.csv"
+	extratoDasFixture     = "parser/testdata/extrato_simples_nacional/extrato_102025.pdf"
+	guiaDasFixture        = "parser/testdata/das/das_102025.pdf"
+	ofxFixture            = "parser/testdata/ofx/extrato_sgml.ofx"
+	interRepeatedFixture  = "parser/testdata/inter/pix_repetido.csv"
+)
+
+func TestMain(m *testing.M) {
+	logger = slog.New(slog.DiscardHandler)
+	os.Exit(m.Run())
+}
+
+// runTestImport importa os arquivos no repositório em memória
+func runTestImport(t *testing.T, repo *db.MemoryRepository, opts importOptions, files ...string) *importReport {
+	t.Helper()
+	return importFiles(repo, parser.NewParserFactory(logger), files, opts)
+}
+
+func TestImportTransactions(t *testing.T) {
+	repo := db.NewMemoryRepository()
+	contaID := repo.AddConta("12345678")
+
+	report := runTestImport(t, repo, importOptions{}, interFixture)
+
+	f := report.Files[0]
+	if f.Status != fileCommitted {
+		t.Fatalf("status = %s (%s), want %s", f.Status, f.Error, fileCommitted)
+	}
+	if want := (recordCounts{Imported: 5}); *f.Transactions != want {
+		t.Errorf("transactions = %+v, want %+v", *f.Transactions, want)
+	}
+
+	transacoes := repo.Transactions()
+	if len(transacoes) != 5 {
+		t.Fatalf("got %d transactions in the repository, want 5", len(transacoes))
+	}
+	for _, tr := range transacoes {
+		if tr.ContaID != contaID || *tr.ImportacaoID != f.ImportacaoID {
+			t.Errorf("transaction %q not linked to conta and importacao", tr.Titulo)
+		}
+	}
+
+	imp := repo.Importacao(f.ImportacaoID)
+	if imp.Status != models.ImportacaoConcluida || imp.QtdImportadas != 5 || imp.FinalizadoEm == nil {
+		t.Errorf("importacao = %+v, want finished with 5 imported", imp)
+	}
+}
+
+func TestImportSkipsDuplicates(t *testing.T) {
+	repo := db.NewMemoryRepository()
+	repo.AddConta("12345678")
+
+	runTestImport(t, repo, importOptions{}, interFixture)
+	report := runTestImport(t, repo, importOptions{}, interFixture)
+
+	if want := (recordCounts{Skipped: 5}); *report.Files[0].Transactions != want {
+		t.Errorf("transactions = %+v, want %+v", *report.Files[0].Transactions, want)
+	}
+	if n := len(repo.Transactions()); n != 5 {
+		t.Errorf("got %d transactions in the repository, want 5", n)
+	}
+}
+
+func TestImportRepeatedRows(t *testing.T) {
+	repo := db.NewMemoryRepository()
+	repo.AddConta("123456789")
+
+	// Os dois Pix idênticos do mesmo dia são gravados; a segunda importação não grava nada
+	report := runTestImport(t, repo, importOptions{}, interRepeatedFixture, interRepeatedFixture)
+
+	if want := (recordCounts{Imported: 3}); *report.Files[0].Transactions != want {
+		t.Errorf("first import transactions = %+v, want %+v", *report.Files[0].Transactions, want)
+	}
+	if want := (recordCounts{Skipped: 3}); *report.Files[1].Transactions != want {
+		t.Errorf("second import transactions = %+v, want %+v", *report.Files[1].Transactions, want)
+	}
+	if n := len(repo.Transactions()); n != 3 {
+		t.Errorf("got %d transactions in the repository, want 3", n)
+	}
+}
+
+func TestImportRefusesParserOverlap(t *testing.T) {
+	repo := db.NewMemoryRepository()
+	repo.AddConta("123456789")
+
+	// O CSV do Inter cobre o mesmo período do OFX já importado, com outros fingerprints
+	report := runTestImport(t, repo, importOptions{}, ofxFixture, interRepeatedFixture)
+
+	if f := report.Files[0]; f.Status != fileCommitted {
+		t.Fatalf("ofx status = %s (%s), want %s", f.Status, f.Error, fileCommitted)
+	}
+	f := report.Files[1]
+	if f.Status != fileRolledBack || !strings.Contains(f.Error, "parser OFX") {
+		t.Errorf("csv status = %s (%s), want %s because of the OFX import", f.Status, f.Error, fileRolledBack)
+	}
+	if n := len(repo.Transactions()); n != 2 {
+		t.Errorf("got %d transactions in the repository, want only the 2 from the OFX", n)
+	}
+
+	factory := parser.NewParserFactory(logger)
+	if preview := previewFile(factory, repo, interRepeatedFixture, importOptions{}); !preview.RolledBack || preview.Error == "" {
+		t.Errorf("preview = %+v, want it to roll back with the overlap error", preview)
+	}
+}
+
+func TestImportInvalidRows(t *testing.T) {
+	tests := []struct {
+		name       string
+		bestEffort bool
+		status     string
+		counts     recordCounts
+		stored     int
+	}{
+		{"atomic", false, fileRolledBack, recordCounts{Failed: 1}, 0},
+		{"best effort", true, fileCommitted, recordCounts{Imported: 1, Failed: 1}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := db.NewMemoryRepository()
+			repo.AddConta("12345678")
+
+			report := runTestImport(t, repo, importOptions{bestEffort: tt.bestEffort}, interInvalidFixture)
+
+			f := report.Files[0]
+			if f.Status != tt.status {
+				t.Fatalf("status = %s (%s), want %s", f.Status, f.Error, tt.status)
+			}
+			if *f.Transactions != tt.counts {
+				t.Errorf("transactions = %+v, want %+v", *f.Transactions, tt.counts)
+			}
+			if len(f.RowErrors) != 1 || f.RowErrors[0].Line != 12 {
+				t.Errorf("row errors = %+v, want the titulo too long at line 12", f.RowErrors)
+			}
+			if n := len(repo.Transactions()); n != tt.stored {
+				t.Errorf("got %d transactions in the repository, want %d", n, tt.stored)
+			}
+		})
+	}
+}
+
+func TestImportZeroValue(t *testing.T) {
+	repo := db.NewMemoryRepository()
+	repo.AddConta("12345678")
+
+	report := runTestImport(t, repo, importOptions{bestEffort: true}, interInvalidFixture)
+
+	// A tarifa de R$ 0,00 da linha 11 é só um diagnóstico: nem gravada, nem rejeitada
+	var found bool
+	for _, d := range report.Files[0].Diagnostics {
+		if d.Line == 11 {
+			found = d.Severity == parser.SeverityInfo
+		}
+	}
+	if !found {
+		t.Errorf("diagnostics = %+v, want info for the zero-value row at line 11", report.Files[0].Diagnostics)
+	}
+	for _, tr := range repo.Transactions() {
+		if tr.Valor == 0 {
+			t.Errorf("zero-value transaction %q was stored", tr.Titulo)
+		}
+	}
+}
+
+func TestImportSaldosDiarios(t *testing.T) {
+	tests := []struct {
+		file      string
+		days      int
+		divergent int
+	}{
+		{interFixture, 5, 0},
+		// Falta uma transação de R$ 100,00 antes de 12/03: os dois últimos dias divergem
+		{interDivergentFixture, 4, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			repo := db.NewMemoryRepository()
+			contaID := repo.AddConta("12345678")
+
+			report := runTestImport(t, repo, importOptions{}, tt.file)
+
+			want := balanceCounts{Days: tt.days, Divergent: tt.divergent}
+			if b := report.Files[0].Balances; b == nil || *b != want {
+				t.Fatalf("balances = %+v, want %+v", b, want)
+			}
+
+			saldos := repo.SaldosDiarios(contaID)
+			if len(saldos) != tt.days {
+				t.Fatalf("got %d saldos diarios in the repository, want %d", len(saldos), tt.days)
+			}
+			for _, s := range saldos {
+				if s.SaldoExtrato == nil {
+					t.Errorf("%s: missing statement balance", s.Data.Format("2006-01-02"))
+				}
+			}
+		})
+	}
+}
+
+func TestImportCategorizes(t *testing.T) {
+	repo := db.NewMemoryRepository()
+	contaID := repo.AddConta("12345678")
+	empresaID := repo.AddEmpresa("11222333000181")
+	repo.SetContaEmpresa(contaID, empresaID)
+	repo.AddPlanoConta(empresaID, "1", "Receita bruta", models.NaturezaReceita)
+	planoContaID := repo.AddPlanoConta(empresaID, "1.1", "Receita de serviços", models.NaturezaReceita)
+
+	titulo, codigo := `^Pix recebido`, "1.1"
+	regraID := repo.AddRegra(models.RegraCategorizacao{
+		Nome: "recebimentos pix", Prioridade: 10, Ativa: true, TituloRegex: &titulo, Categoria: "Receitas", CodigoPlano: &codigo,
+	})
+
+	runTestImport(t, repo, importOptions{}, interFixture)
+
+	var categorized int
+	for _, tr := range repo.Transactions() {
+		if tr.Categoria == nil {
+			continue
+		}
+		categorized++
+		if *tr.Categoria != "Receitas" || tr.RegraID == nil || *tr.RegraID != regraID {
+			t.Errorf("transaction %q categorized as %v by %v", tr.Titulo, *tr.Categoria, tr.RegraID)
+		}
+		if tr.PlanoContaID == nil || *tr.PlanoContaID != planoContaID {
+			t.Errorf("transaction %q linked to plano conta %v, want %s", tr.Titulo, tr.PlanoContaID, planoContaID)
+		}
+	}
+	if categorized != 2 {
+		t.Errorf("got %d categorized transactions, want the 2 pix received", categorized)
+	}
+}
+
+func TestImportContrapartes(t *testing.T) {
+	repo := db.NewMemoryRepository()
+	repo.AddConta("12345678")
+
+	runTestImport(t, repo, importOptions{}, interFixture)
+
+	// "Cliente Exemplo SA" aparece em dois Pix recebidos, um deles com o ISPB do banco
+	contrapartes := repo.Contrapartes()
+	cliente, ok := contrapartes["nome:CLIENTE EXEMPLO SA"]
+	if len(contrapartes) != 3 || !ok {
+		t.Fatalf("contrapartes = %+v, want fornecedor, cliente and fulano", contrapartes)
+	}
+	if cliente.ISPB == nil || *cliente.ISPB != "00000000" {
+		t.Errorf("cliente ispb = %v, want 00000000", cliente.ISPB)
+	}
+
+	var linked int
+	for _, tr := range repo.Transactions() {
+		if tr.ContraparteID == nil {
+			continue
+		}
+		linked++
+		if tr.Titulo == "Pix recebido" && *tr.ContraparteID != cliente.ID {
+			t.Errorf("pix received on %s linked to %s, want the cliente %s", tr.Data.Format("2006-01-02"), *tr.ContraparteID, cliente.ID)
+		}
+	}
+	if linked != 4 {
+		t.Errorf("got %d transactions linked to a contraparte, want the 4 pix", linked)
+	}
+}
+
+func TestImportOFXContaNumero(t *testing.T) {
+	// O OFX traz "12345678-9"; a conta é encontrada qualquer que seja o formato do cadastro
+	for _, numero := range []string{"123456789", "12345678-9", "12.345.678-9"} {
+		t.Run(numero, func(t *testing.T) {
+			repo := db.NewMemoryRepository()
+			contaID := repo.AddConta(numero)
+
+			report := runTestImport(t, repo, importOptions{}, ofxFixture)
+
+			f := report.Files[0]
+			if f.Status != fileCommitted {
+				t.Fatalf("status = %s (%s), want %s", f.Status, f.Error, fileCommitted)
+			}
+			if imp := repo.Importacao(f.ImportacaoID); imp.ContaID == nil || *imp.ContaID != contaID {
+				t.Errorf("importacao conta = %v, want %s", imp.ContaID, contaID)
+			}
+		})
+	}
+}
+
+func TestImportAmbiguousConta(t *testing.T) {
+	repo := db.NewMemoryRepository()
+	repo.AddConta("12345678-9")
+	repo.AddConta("123456789")
+
+	report := runTestImport(t, repo, importOptions{}, ofxFixture)
+
+	f := report.Files[0]
+	if f.Status != fileRolledBack || !strings.Contains(f.Error, "more than one conta") {
+		t.Errorf("status = %s (%s), want %s with an ambiguity error", f.Status, f.Error, fileRolledBack)
+	}
+}
+
+func TestImportUnknownConta(t *testing.T) {
+	repo := db.NewMemoryRepository()
+
+	report := runTestImport(t, repo, importOptions{}, interFixture)
+
+	f := report.Files[0]
+	if f.Status != fileRolledBack {
+		t.Fatalf("status = %s, want %s", f.Status, fileRolledBack)
+	}
+	if imp := repo.Importacao(f.ImportacaoID); imp.Status != models.ImportacaoFalha || imp.MensagemErro == nil {
+		t.Errorf("importacao = %+v, want failed with an error message", imp)
+	}
+	if !report.failed() {
+		t.Error("report should be marked as failed")
+	}
+}
+
+func TestImportDasDocumento(t *testing.T) {
+	repo := db.NewMemoryRepository()
+	empresaID := repo.AddEmpresa("11222333000181")
+
+	report := runTestImport(t, repo, importOptions{}, extratoDasFixture, extratoDasFixture)
+
+	if want := (recordCounts{Imported: 1}); *report.Files[0].Das != want {
+		t.Errorf("first import das = %+v, want %+v", *report.Files[0].Das, want)
+	}
+	if want := (recordCounts{Skipped: 1}); *report.Files[1].Das != want {
+		t.Errorf("second import das = %+v, want %+v", *report.Files[1].Das, want)
+	}
+
+	das := repo.DasDocumentos()
+	if len(das) != 1 {
+		t.Fatalf("got %d das documentos in the repository, want 1", len(das))
+	}
+	if das[0].EmpresaID != empresaID || das[0].NumeroDocumento != "07202529630527916" {
+		t.Errorf("das documento = %+v", das[0])
+	}
+}
+
+func TestImportDasPeriodoConflict(t *testing.T) {
+	repo := db.NewMemoryRepository()
+	empresaID := repo.AddEmpresa("11222333000181")
+
+	// Outro documento da empresa para o mesmo período viola uq_das_empresa_periodo
+	tx, _ := repo.Begin()
+	periodo := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := tx.InsertDasDocumento("", empresaID, periodo, periodo, "00000000000000001", 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	report := runTestImport(t, repo, importOptions{}, extratoDasFixture)
+
+	f := report.Files[0]
+	if f.Status != fileCommitted {
+		t.Fatalf("status = %s (%s), want %s", f.Status, f.Error, fileCommitted)
+	}
+	if want := (recordCounts{Skipped: 1}); *f.Das != want {
+		t.Errorf("das = %+v, want %+v", *f.Das, want)
+	}
+	if len(f.RowErrors) != 0 {
+		t.Errorf("row errors = %+v, want none", f.RowErrors)
+	}
+	if n := len(repo.DasDocumentos()); n != 1 {
+		t.Errorf("got %d das documentos in the repository, want 1", n)
+	}
+}
+
+func TestPreviewDasMatchesImport(t *testing.T) {
+	repo := db.NewMemoryRepository()
+	repo.AddEmpresa("11222333000181")
+	factory := parser.NewParserFactory(logger)
+
+	// A guia do DAS não é importada; o dry-run não pode anunciar o documento
+	if preview := previewFile(factory, repo, guiaDasFixture, importOptions{}); preview.Das != nil || preview.Counts.New != 0 {
+		t.Errorf("guia preview = %+v, want nothing to import", preview)
+	}
+	runTestImport(t, repo, importOptions{}, guiaDasFixture)
+	if das := repo.DasDocumentos(); len(das) != 0 {
+		t.Fatalf("got %d das documentos after importing the guia, want 0", len(das))
+	}
+
+	if preview := previewFile(factory, repo, extratoDasFixture, importOptions{}); preview.Das == nil || preview.Das.Status != previewNew {
+		t.Errorf("extrato preview das = %+v, want %s", preview.Das, previewNew)
+	}
+}
+
+func TestPreviewDasNumeroOtherEmpresa(t *testing.T) {
+	repo := db.NewMemoryRepository()
+	outraID := repo.AddEmpresa("99888777000166")
+	repo.AddEmpresa("11222333000181")
+
+	// uq_das_numero_documento vale para todas as empresas
+	tx, _ := repo.Begin()
+	periodo := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := tx.InsertDasDocumento("", outraID, periodo, periodo, "07202529630527916", 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	preview := previewFile(parser.NewParserFactory(logger), repo, extratoDasFixture, importOptions{})
+	if preview.Das == nil || preview.Das.Status != previewDuplicate {
+		t.Fatalf("preview das = %+v, want %s", preview.Das, previewDuplicate)
+	}
+
+	report := runTestImport(t, repo, importOptions{}, extratoDasFixture)
+	if want := (recordCounts{Skipped: 1}); *report.Files[0].Das != want {
+		t.Errorf("import das = %+v, want %+v like the preview", *report.Files[0].Das, want)
+	}
+}
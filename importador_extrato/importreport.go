@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/db"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/parser"
+)
+
+// Situação final de cada arquivo no relatório
+const (
+	fileCommitted  = "committed"   // transação do arquivo confirmada
+	fileRolledBack = "rolled_back" // nada do arquivo foi gravado
+)
+
+// importReport é o relatório da execução do comando import, que pode ser gravado em JSON
+// para monitoramento
+type importReport struct {
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	BestEffort bool          `json:"best_effort"`
+	Files      []*fileReport `json:"files"`
+	Totals     reportTotals  `json:"totals"`
+}
+
+// fileReport é o resultado da importação de um arquivo
+type fileReport struct {
+	Path         string          `json:"path"`
+	ImportacaoID string          `json:"importacao_id,omitempty"`
+	Parser       string          `json:"parser,omitempty"`
+	Account      string          `json:"account,omitempty"`
+	Period       string          `json:"period,omitempty"`
+	Status       string          `json:"status"`
+	Error        string          `json:"error,omitempty"`
+	Transactions *recordCounts   `json:"transactions,omitempty"` // preenchido para extratos bancários
+	Das          *recordCounts   `json:"das,omitempty"`          // preenchido para documentos DAS
+	Balances     *balanceCounts  `json:"balances,omitempty"`     // saldos diários gravados para extratos bancários
+	Diagnostics  []rowDiagnostic `json:"diagnostics"`            // problemas encontrados pelo parser
+	RowErrors    []rowError      `json:"row_errors"`             // registros rejeitados pelo banco
+}
+
+// rowDiagnostic é um problema encontrado pelo parser em um registro do arquivo
+type rowDiagnostic struct {
+	Line     int             `json:"line,omitempty"`
+	Record   string          `json:"record,omitempty"`
+	Reason   string          `json:"reason"`
+	Severity parser.Severity `json:"severity"`
+}
+
+// newRowDiagnostics converte os diagnósticos do extrato para o relatório
+func newRowDiagnostics(stmt *parser.Statement) []rowDiagnostic {
+	diagnostics := make([]rowDiagnostic, len(stmt.Diagnostics))
+	for i, d := range stmt.Diagnostics {
+		diagnostics[i] = rowDiagnostic{Line: d.Line, Record: d.Record, Reason: d.Reason, Severity: d.Severity}
+	}
+	return diagnostics
+}
+
+// recordCounts conta os registros de um tipo gravados, ignorados e rejeitados
+type recordCounts struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+	Failed   int `json:"failed"`
+}
+
+func (c *recordCounts) add(other *recordCounts) {
+	if other == nil {
+		return
+	}
+	c.Imported += other.Imported
+	c.Skipped += other.Skipped
+	c.Failed += other.Failed
+}
+
+// balanceCounts conta os saldos diários gravados e os que divergem do saldo calculado
+type balanceCounts struct {
+	Days      int `json:"days"`
+	Divergent int `json:"divergent"`
+}
+
+// rowError é um registro do arquivo rejeitado na importação
+type rowError struct {
+	Line        int    `json:"line,omitempty"`
+	Date        string `json:"date"`
+	Description string `json:"description"`
+	Constraint  string `json:"constraint,omitempty"` // restrição do banco violada, se houver
+	Error       string `json:"error"`
+}
+
+// reportTotals soma os resultados de todos os arquivos
+type reportTotals struct {
+	Files        int          `json:"files"`
+	Committed    int          `json:"committed"`
+	RolledBack   int          `json:"rolled_back"`
+	Transactions recordCounts `json:"transactions"`
+	Das          recordCounts `json:"das"`
+	Diagnostics  int          `json:"diagnostics"`
+	Divergent    int          `json:"divergent_balances"`
+}
+
+// addRowError registra a transação do extrato rejeitada
+func (f *fileReport) addRowError(t parser.Transaction, err error) {
+	f.RowErrors = append(f.RowErrors, rowError{
+		Line:        t.Line,
+		Date:        t.Date.Format("2006-01-02"),
+		Description: t.Description,
+		Constraint:  db.ConstraintName(err),
+		Error:       err.Error(),
+	})
+}
+
+// add inclui o arquivo no relatório e nos totais
+func (r *importReport) add(f *fileReport) {
+	r.Files = append(r.Files, f)
+	r.Totals.Files++
+	if f.Status == fileCommitted {
+		r.Totals.Committed++
+	} else {
+		r.Totals.RolledBack++
+	}
+	r.Totals.Transactions.add(f.Transactions)
+	r.Totals.Das.add(f.Das)
+	r.Totals.Diagnostics += len(f.Diagnostics)
+	if f.Balances != nil {
+		r.Totals.Divergent += f.Balances.Divergent
+	}
+}
+
+// failed informa se algum arquivo ou registro falhou
+func (r *importReport) failed() bool {
+	return r.Totals.RolledBack > 0 || r.Totals.Transactions.Failed > 0 || r.Totals.Das.Failed > 0
+}
+
+// writeJSON grava o relatório em JSON
+func (r *importReport) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// writeJSONFile grava o relatório em JSON no arquivo informado
+func (r *importReport) writeJSONFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating report file: %v", err)
+	}
+
+	if err := r.writeJSON(file); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing report file: %v", err)
+	}
+
+	return file.Close()
+}
+
+// printSummary mostra os totais da execução
+func (r *importReport) printSummary(w io.Writer) {
+	t := r.Totals
+	fmt.Fprintf(w, "\n=== Final Import Summary ===\n")
+	fmt.Fprintf(w, "Files processed: %d\n", t.Files)
+	fmt.Fprintf(w, "Files committed: %d\n", t.Committed)
+	fmt.Fprintf(w, "Files rolled back: %d\n", t.RolledBack)
+	fmt.Fprintf(w, "Transactions imported: %d\n", t.Transactions.Imported)
+	fmt.Fprintf(w, "Transactions skipped (duplicates): %d\n", t.Transactions.Skipped)
+	fmt.Fprintf(w, "Transactions rejected: %d\n", t.Transactions.Failed)
+	fmt.Fprintf(w, "Total transactions processed: %d\n", t.Transactions.Imported+t.Transactions.Skipped+t.Transactions.Failed)
+	fmt.Fprintf(w, "DAS documents imported: %d\n", t.Das.Imported)
+	fmt.Fprintf(w, "DAS documents skipped (duplicates): %d\n", t.Das.Skipped)
+	fmt.Fprintf(w, "DAS documents rejected: %d\n", t.Das.Failed)
+	fmt.Fprintf(w, "Parser diagnostics: %d\n", t.Diagnostics)
+	fmt.Fprintf(w, "Divergent daily balances: %d\n", t.Divergent)
+}
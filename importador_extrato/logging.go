@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/config"
+)
+
+// logger é o logger dos comandos, configurado pelas flags -log-level e -log-format.
+// Escreve na saída de erros, deixando a saída padrão para relatórios e tabelas.
+var logger = slog.Default()
+
+var logLevel, logFormat string
+
+// addLogFlags registra as flags de log, comuns a todos os subcomandos
+func addLogFlags(fs *flag.FlagSet) {
+	level, format := config.LoadLogConfig()
+	fs.StringVar(&logLevel, "log-level", level, "nível do log: debug, info, warn ou error (padrão: variável LOG_LEVEL)")
+	fs.StringVar(&logFormat, "log-format", format, "formato do log: text ou json (padrão: variável LOG_FORMAT)")
+}
+
+// setupLogger cria o logger com o nível e o formato informados e o torna o padrão do slog
+func setupLogger() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(logLevel)); err != nil {
+		return fmt.Errorf("invalid log level %q (use debug, info, warn or error)", logLevel)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch logFormat {
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("invalid log format %q (use text or json)", logFormat)
+	}
+
+	logger = slog.New(handler)
+	slog.SetDefault(logger)
+	return nil
+}
@@ -1,227 +1,115 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"log"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/config"
 	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/db"
-	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/parser"
 )
 
-func main() {
-	// Load configuration
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		log.Fatalf("Error loading config: %v", err)
-	}
-
-	// Connect to database
-	database, err := db.NewConnection(cfg.GetConnectionString())
-	if err != nil {
-		log.Fatalf("Error connecting to database: %v", err)
-	}
-	defer database.Close()
+// Códigos de saída, para uso em scripts (run.sh, cron)
+const (
+	exitOK      = 0 // comando concluído sem erros
+	exitFailure = 1 // comando executado, mas algum arquivo ou registro falhou
+	exitUsage   = 2 // argumentos inválidos
+	exitConfig  = 3 // configuração ausente ou banco de dados indisponível
+)
 
-	// Create parser factory
-	factory := parser.NewParserFactory()
+// command é um subcomando da linha de comando
+type command struct {
+	name        string
+	description string
+	run         func(args []string) int
+}
 
-	fmt.Println("=== Importador de Extratos ===")
-	fmt.Printf("Parsers disponíveis: %s\n\n", strings.Join(factory.ListSupportedParsers(), ", "))
+var commands = []command{
+	{"import", "importa extratos e documentos DAS para o banco de dados", runImport},
+	{"parsers", "lista os parsers registrados", runParsers},
+	{"accounts", "lista as contas bancárias cadastradas", runAccounts},
+	{"report", "resume as transações importadas por conta e mês", runReport},
+	{"imports", "lista as importações realizadas", runImports},
+	{"revert", "desfaz uma importação", runRevert},
+	{"rules", "lista as regras de categorização", runRules},
+	{"categorize", "aplica as regras de categorização às transações gravadas", runCategorize},
+	{"chart", "gerencia o plano de contas das empresas", runChart},
+	{"transfers", "encontra as transferências entre as contas das nossas empresas", runTransfers},
+	{"reconcile", "concilia os documentos DAS com os pagamentos nos extratos", runReconcile},
+}
 
-	// List all supported files in the rawdata/extrato directory
-	files, err := findImportableFiles("./rawdata/extrato")
-	if err != nil {
-		log.Fatalf("Error listing files: %v", err)
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(exitUsage)
 	}
 
-	if len(files) == 0 {
-		log.Fatal("No importable files found in ./rawdata/extrato")
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		os.Exit(exitOK)
 	}
 
-	fmt.Printf("Found %d file(s) to process\n\n", len(files))
-
-	// Process each file
-	var totalImported, totalSkipped, totalErrors int
-
-	for fileIndex, filePath := range files {
-		fmt.Printf("\n=== Processing file %d/%d: %s ===\n", fileIndex+1, len(files), filepath.Base(filePath))
-
-		// Get appropriate parser for this file
-		p, err := factory.GetParser(filePath)
-		if err != nil {
-			log.Printf("Skipping file %s: %v\n", filepath.Base(filePath), err)
-			totalErrors++
-			continue
-		}
-
-		fmt.Printf("Using parser: %s\n", p.GetName())
-
-		// Parse file
-		stmt, err := p.Parse(filePath)
-		if err != nil {
-			log.Printf("Error parsing file %s: %v - skipping file\n", filepath.Base(filePath), err)
-			totalErrors++
-			continue
-		}
-
-		// Validate account number
-		if strings.TrimSpace(stmt.AccountNumber) == "" {
-			log.Printf("Account number must be informed in the file %s - skipping file\n", filepath.Base(filePath))
-			totalErrors++
-			continue
-		}
-
-		var fileImported, fileSkipped int
-
-		switch stmt.AccountNumber {
-		case "das-simples-nacional":
-		case "extrato-simples-nacional":
-			// Get conta ID from database
-			empresaID, err := database.GetEmpresaIDByCNPJ(stmt.DasDocumento.CNPJ)
-			if err != nil {
-				log.Printf("Error finding conta ID for %s: %v - skipping file\n", stmt.AccountNumber, err)
-				totalErrors++
-				continue
-			}
-
-			// Import das ducumento
-			fmt.Printf("Importing DAS documento for empresa %s...\n", empresaID)
-
-			err = database.InsertDasDocumento(
-				empresaID,
-				stmt.DasDocumento.PeriodoApuracao,
-				stmt.DasDocumento.DataVencimento,
-				stmt.DasDocumento.NumeroDocumento,
-				stmt.DasDocumento.ValorTotal,
-			)
-
-			if err != nil {
-				if isUniqueViolation(err) {
-					fileSkipped++
-					continue
-				}
-				log.Printf("Error inserting das documento: %v - skipping \n", err)
-				continue
-			}
-
-			fileImported++
-
-		default:
-			// Get conta ID from database
-			contaID, err := database.GetContaIDByNumero(stmt.AccountNumber)
-			if err != nil {
-				log.Printf("Error finding conta ID for %s: %v - skipping file\n", stmt.AccountNumber, err)
-				totalErrors++
-				continue
-			}
-
-			// Import transactions
-			fmt.Printf("Importing %d transaction(s) for account %s...\n", len(stmt.Transactions), stmt.AccountNumber)
-
-			for _, tx := range stmt.Transactions {
-				err := database.InsertTransaction(
-					contaID,
-					tx.Date,
-					tx.Description,
-					tx.Details,
-					tx.Amount,
-				)
-
-				if err != nil {
-					if isUniqueViolation(err) {
-						fileSkipped++
-						continue
-					}
-					log.Printf("Error inserting transaction: %v - skipping transaction\n", err)
-					continue
-				}
-
-				fileImported++
-			}
-
-			totalImported += fileImported
-			totalSkipped += fileSkipped
-
+	for _, cmd := range commands {
+		if cmd.name == name {
+			os.Exit(cmd.run(os.Args[2:]))
 		}
-
-		fmt.Printf("✓ File import completed!\n")
-		fmt.Printf("  Imported: %d transaction(s)\n", fileImported)
-		fmt.Printf("  Skipped (duplicates): %d transaction(s)\n", fileSkipped)
-		fmt.Printf("  Total processed: %d transaction(s)\n", len(stmt.Transactions))
 	}
 
-	fmt.Printf("\n=== Final Import Summary ===\n")
-	fmt.Printf("Files processed: %d\n", len(files))
-	fmt.Printf("Files with errors: %d\n", totalErrors)
-	fmt.Printf("Transactions imported: %d\n", totalImported)
-	fmt.Printf("Transactions skipped: %d\n", totalSkipped)
-	fmt.Printf("Total transactions processed: %d\n", totalImported+totalSkipped)
+	fmt.Fprintf(os.Stderr, "Comando desconhecido: %s\n\n", name)
+	usage()
+	os.Exit(exitUsage)
 }
 
-// findImportableFiles busca recursivamente por arquivos suportados
-func findImportableFiles(rootDir string) ([]string, error) {
-	var files []string
-
-	// Busca por CSVs (Inter e Nubank)
-	csvFiles, err := filepath.Glob(filepath.Join(rootDir, "**/*.csv"))
-	if err != nil {
-		return nil, fmt.Errorf("error listing CSV files: %v", err)
+func usage() {
+	fmt.Fprintf(os.Stderr, "Uso: %s <comando> [opções]\n\nComandos:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.description)
 	}
-	files = append(files, csvFiles...)
+	fmt.Fprintf(os.Stderr, "\nUse \"%s <comando> -h\" para ver as opções de cada comando.\n", os.Args[0])
+}
 
-	// Busca por PDFs (Simples Nacional)
-	pdfFiles, err := filepath.Glob(filepath.Join(rootDir, "**/*.pdf"))
-	if err != nil {
-		return nil, fmt.Errorf("error listing PDF files: %v", err)
+// newFlagSet cria o conjunto de flags de um subcomando; erros de parse saem com exitUsage
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Uso: %s\n\nOpções:\n", strings.TrimSpace(fmt.Sprintf("%s %s [opções] %s", os.Args[0], name, args)))
+		fs.PrintDefaults()
 	}
-	files = append(files, pdfFiles...)
+	addLogFlags(fs)
+	return fs
+}
 
-	// Como glob com ** não funciona sempre, vamos fazer busca manual
-	if len(files) == 0 {
-		files, err = findFilesRecursive(rootDir, []string{".csv", ".pdf"})
-		if err != nil {
-			return nil, err
+// parseFlags interpreta os argumentos do subcomando e configura o logger,
+// retornando o código de saída se não for para continuar
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK, false
 		}
+		return exitUsage, false
 	}
-
-	return files, nil
+	if err := setupLogger(); err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		return exitUsage, false
+	}
+	return exitOK, true
 }
 
-// findFilesRecursive busca arquivos recursivamente
-func findFilesRecursive(rootDir string, extensions []string) ([]string, error) {
-	var files []string
-
-	err := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if info.IsDir() {
-			return nil
-		}
-
-		ext := strings.ToLower(filepath.Ext(path))
-		for _, validExt := range extensions {
-			if ext == validExt {
-				files = append(files, path)
-				break
-			}
-		}
-
-		return nil
-	})
-
+// connect carrega a configuração e conecta ao banco de dados
+func connect() (*config.Config, *db.DB, error) {
+	// Load configuration
+	cfg, err := config.LoadConfig()
 	if err != nil {
-		return nil, fmt.Errorf("error walking directory: %v", err)
+		return nil, nil, fmt.Errorf("error loading config: %v", err)
 	}
 
-	return files, nil
-}
+	// Connect to database
+	database, err := db.NewConnection(cfg.GetConnectionString(), logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error connecting to database: %v", err)
+	}
 
-func isUniqueViolation(err error) bool {
-	return err != nil && err.Error() == "duplicate key value violates unique constraint"
+	return cfg, database, nil
 }
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
+)
+
+// Conta representa uma conta bancária de uma empresa
+type Conta struct {
+	ID           string         `db:"id"`
+	EmpresaID    string         `db:"empresa_id"`
+	Banco        string         `db:"banco"`
+	Agencia      *string        `db:"agencia"`
+	Numero       *string        `db:"numero"`
+	Nome         string         `db:"nome"`
+	SaldoInicial money.Centavos `db:"saldo_inicial"`
+	Ativo        bool           `db:"ativo"`
+	CriadoEm     time.Time      `db:"criado_em"`
+	AtualizadoEm time.Time      `db:"atualizado_em"`
+}
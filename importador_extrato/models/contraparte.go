@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Contraparte é o cliente, fornecedor ou outra parte de transferências e pagamentos
+type Contraparte struct {
+	ID           string    `db:"id"`
+	Chave        string    `db:"chave"` // identifica a contraparte entre extratos; veja db.NewContraparte
+	Nome         string    `db:"nome"`
+	Documento    *string   `db:"documento"` // CPF ou CNPJ sem máscara; dígitos ocultos de CPF mascarado como '*'
+	BancoNome    *string   `db:"banco_nome"`
+	BancoCodigo  *string   `db:"banco_codigo"`
+	ISPB         *string   `db:"ispb"`
+	Agencia      *string   `db:"agencia"`
+	Conta        *string   `db:"conta"`
+	CriadoEm     time.Time `db:"criado_em"`
+	AtualizadoEm time.Time `db:"atualizado_em"`
+}
@@ -1,16 +1,35 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
+)
+
+// Status dos documentos DAS
+const (
+	DasEmitido   = "EMITIDO"
+	DasPago      = "PAGO"
+	DasVencido   = "VENCIDO"
+	DasCancelado = "CANCELADO"
+)
 
 type DasDocumento struct {
-	ID              string    `db:"id"`
-	EmpresaID       string    `db:"empresa_id"`
-	PeriodoApuracao time.Time `db:"periodo_apuracao"`
-	DataVencimento  time.Time `db:"data_vencimento"`
-	NumeroDocumento string    `db:"numero_documento"`
-	ValorTotal      float64   `db:"valor_total"`
-	Status          string    `db:"status"` // EMITIDO, PAGO, VENCIDO, CANCELADO
-	ArquivoPath     *string   `db:"arquivo_path"`
-	CriadoEm        time.Time `db:"criado_em"`
-	AtualizadoEm    time.Time `db:"atualizado_em"`
+	ID              string         `db:"id"`
+	EmpresaID       string         `db:"empresa_id"`
+	PeriodoApuracao time.Time      `db:"periodo_apuracao"`
+	DataVencimento  time.Time      `db:"data_vencimento"`
+	NumeroDocumento string         `db:"numero_documento"`
+	ValorTotal      money.Centavos `db:"valor_total"`
+	Status          string         `db:"status"` // EMITIDO, PAGO, VENCIDO, CANCELADO
+	ArquivoPath     *string        `db:"arquivo_path"`
+	CriadoEm        time.Time      `db:"criado_em"`
+	AtualizadoEm    time.Time      `db:"atualizado_em"`
+	ImportacaoID    *string        `db:"importacao_id"`
+	TransacaoID     *string        `db:"transacao_id"` // débito bancário que pagou o documento
+}
+
+// Modificado indica se o documento foi alterado depois de importado (ex: conciliado com o pagamento)
+func (d *DasDocumento) Modificado() bool {
+	return d.Status != DasEmitido || d.AtualizadoEm.After(d.CriadoEm)
 }
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Importacao representa o processamento de um arquivo pelo importador
+type Importacao struct {
+	ID            string     `db:"id"`
+	ArquivoNome   string     `db:"arquivo_nome"`
+	ArquivoSHA256 string     `db:"arquivo_sha256"`
+	Parser        *string    `db:"parser"`
+	ContaID       *string    `db:"conta_id"`
+	EmpresaID     *string    `db:"empresa_id"`
+	Status        string     `db:"status"` // EM_ANDAMENTO, CONCLUIDA, FALHA, REVERTIDA
+	MensagemErro  *string    `db:"mensagem_erro"`
+	QtdImportadas int        `db:"qtd_importadas"`
+	QtdIgnoradas  int        `db:"qtd_ignoradas"`
+	QtdFalhas     int        `db:"qtd_falhas"`
+	IniciadoEm    time.Time  `db:"iniciado_em"`
+	FinalizadoEm  *time.Time `db:"finalizado_em"`
+	CriadoEm      time.Time  `db:"criado_em"`
+	AtualizadoEm  time.Time  `db:"atualizado_em"`
+}
+
+// Status possíveis de uma importação
+const (
+	ImportacaoEmAndamento = "EM_ANDAMENTO"
+	ImportacaoConcluida   = "CONCLUIDA"
+	ImportacaoFalha       = "FALHA"
+	ImportacaoRevertida   = "REVERTIDA"
+)
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Naturezas das contas do plano, que agrupam as contas nas linhas da DRE
+const (
+	NaturezaReceita        = "receita"
+	NaturezaDeducao        = "deducao"
+	NaturezaCusto          = "custo"
+	NaturezaDespesa        = "despesa"
+	NaturezaNaoOperacional = "nao_operacional" // transferências internas, aportes: fora da DRE
+)
+
+// PlanoConta é uma conta do plano de contas gerencial de uma empresa
+type PlanoConta struct {
+	ID           string    `db:"id"`
+	EmpresaID    string    `db:"empresa_id"`
+	ParentID     *string   `db:"parent_id"`
+	Codigo       string    `db:"codigo"` // hierárquico: a conta pai de "4.1.01" é "4.1"
+	Nome         string    `db:"nome"`
+	Natureza     string    `db:"natureza"`
+	Ativa        bool      `db:"ativa"`
+	CriadoEm     time.Time `db:"criado_em"`
+	AtualizadoEm time.Time `db:"atualizado_em"`
+}
+
+// CodigoPai retorna o código da conta pai, ou "" para as contas do primeiro nível
+func (p *PlanoConta) CodigoPai() string {
+	i := strings.LastIndex(p.Codigo, ".")
+	if i < 0 {
+		return ""
+	}
+	return p.Codigo[:i]
+}
+
+// Nivel retorna a profundidade da conta na árvore, começando em 1
+func (p *PlanoConta) Nivel() int {
+	return strings.Count(p.Codigo, ".") + 1
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
+)
+
+// RegraCategorizacao define a categoria das transações que atendem a todos os critérios informados.
+// Critérios nulos não restringem.
+type RegraCategorizacao struct {
+	ID             string          `db:"id"`
+	Nome           string          `db:"nome"`
+	Prioridade     int             `db:"prioridade"` // menor é avaliada primeiro
+	Ativa          bool            `db:"ativa"`
+	TituloRegex    *string         `db:"titulo_regex"`
+	DescricaoRegex *string         `db:"descricao_regex"`
+	Documento      *string         `db:"documento"` // CNPJ ou CPF da contraparte, sem máscara
+	ValorMin       *money.Centavos `db:"valor_min"`
+	ValorMax       *money.Centavos `db:"valor_max"`
+	ContaID        *string         `db:"conta_id"`
+	TipoOperacao   *string         `db:"tipo_operacao"` // credito ou debito
+	Categoria      string          `db:"categoria"`
+	Subcategoria   *string         `db:"subcategoria"`
+	CodigoPlano    *string         `db:"plano_conta_codigo"` // conta do plano da empresa titular da conta bancária
+	CriadoEm       time.Time       `db:"criado_em"`
+	AtualizadoEm   time.Time       `db:"atualizado_em"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
+)
+
+// SaldoDiario representa o saldo de uma conta ao fim do dia
+type SaldoDiario struct {
+	ContaID        string          `db:"conta_id"`
+	Data           time.Time       `db:"data"`
+	SaldoExtrato   *money.Centavos `db:"saldo_extrato"`   // informado pelo banco; nil se o extrato não traz saldo
+	SaldoCalculado money.Centavos  `db:"saldo_calculado"` // saldo inicial da conta mais as transações até a data
+	Divergente     bool            `db:"divergente"`
+	ImportacaoID   *string         `db:"importacao_id"`
+	CriadoEm       time.Time       `db:"criado_em"`
+	AtualizadoEm   time.Time       `db:"atualizado_em"`
+}
@@ -2,19 +2,37 @@ package models
 
 import (
 	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
 )
 
 // Transaction represents a financial transaction in the database
 type Transaction struct {
-	ID            string    `db:"id"`
-	ContaID       string    `db:"conta_id"`
-	Data          time.Time `db:"data"`
-	Titulo        string    `db:"titulo"`
-	Descricao     string    `db:"descricao"`
-	TipoOperacao  string    `db:"tipo_operacao"`
-	TipoTransacao string    `db:"tipo_transacao"`
-	Valor         float64   `db:"valor"`
-	CriadoEm      time.Time `db:"criado_em"`
-	AtualizadoEm  time.Time `db:"atualizado_em"`
-	Fingerprint   string    `db:"fingerprint"`
+	ID            string         `db:"id"`
+	ContaID       string         `db:"conta_id"`
+	Data          time.Time      `db:"data"`
+	Titulo        string         `db:"titulo"`
+	Descricao     string         `db:"descricao"`
+	TipoOperacao  string         `db:"tipo_operacao"`
+	TipoTransacao string         `db:"tipo_transacao"`
+	Valor         money.Centavos `db:"valor"`
+	CriadoEm      time.Time      `db:"criado_em"`
+	AtualizadoEm  time.Time      `db:"atualizado_em"`
+	Fingerprint   string         `db:"fingerprint"`
+	ImportacaoID  *string        `db:"importacao_id"`
+	Categoria     *string        `db:"categoria"`
+	Subcategoria  *string        `db:"subcategoria"`
+	RegraID       *string        `db:"regra_id"` // regra que definiu a categoria; nil com categoria preenchida indica categorização manual
+	PlanoContaID  *string        `db:"plano_conta_id"`
+	ContraparteID *string        `db:"contraparte_id"`
+}
+
+// CategorizadaManualmente indica se a categoria foi definida à mão, e não por uma regra
+func (t *Transaction) CategorizadaManualmente() bool {
+	return t.RegraID == nil && (t.Categoria != nil || t.PlanoContaID != nil)
+}
+
+// Modificada indica se a transação foi alterada depois de importada (ex: categorizada)
+func (t *Transaction) Modificada() bool {
+	return t.AtualizadoEm.After(t.CriadoEm)
 }
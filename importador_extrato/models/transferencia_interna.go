@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// TransferenciaInterna liga o débito em uma conta das nossas empresas ao crédito correspondente em outra
+type TransferenciaInterna struct {
+	ID        string    `db:"id"`
+	DebitoID  string    `db:"debito_id"`
+	CreditoID string    `db:"credito_id"`
+	CriadoEm  time.Time `db:"criado_em"`
+}
@@ -0,0 +1,184 @@
+package money
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Centavos representa um valor monetário em centavos de real.
+// Valores negativos são débitos e positivos são créditos.
+type Centavos int64
+
+// ParseBR converte valores no formato brasileiro ("R$ -1.234,56", "1234,5") para centavos. O sinal
+// também pode vir depois do valor, como nos extratos em PDF: "1.234,56-" e "1.234,56 D" são
+// débitos, "1.234,56 C" é crédito.
+func ParseBR(s string) (Centavos, error) {
+	return parse(s, ',', '.', true)
+}
+
+// ParseDecimal converte valores com ponto como separador decimal ("-1234.56", "50") para centavos.
+// O sinal só é aceito antes do valor.
+func ParseDecimal(s string) (Centavos, error) {
+	return parse(s, '.', ',', false)
+}
+
+// parse converte o valor; com signSuffix, aceita também o sinal depois dele ("-", "D" ou "C")
+func parse(s string, decimalSep, thousandsSep byte, signSuffix bool) (Centavos, error) {
+	clean := strings.ReplaceAll(s, "R$", "")
+	clean = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\u00a0':
+			return -1
+		}
+		return r
+	}, clean)
+
+	negative, signed := false, false
+	switch {
+	case strings.HasPrefix(clean, "-"):
+		negative, signed = true, true
+		clean = clean[1:]
+	case strings.HasPrefix(clean, "+"):
+		signed = true
+		clean = clean[1:]
+	}
+
+	// Sinal depois do valor, que não pode se somar a um sinal antes dele
+	if n := len(clean); signSuffix && n > 0 && strings.IndexByte("-DdCc", clean[n-1]) >= 0 {
+		if signed {
+			return 0, fmt.Errorf("valor inválido (%q)", s)
+		}
+		negative = strings.IndexByte("-Dd", clean[n-1]) >= 0
+		clean = clean[:n-1]
+	}
+
+	clean = strings.ReplaceAll(clean, string(thousandsSep), "")
+
+	intPart, fracPart, _ := strings.Cut(clean, string(decimalSep))
+	if intPart == "" && fracPart == "" {
+		return 0, fmt.Errorf("valor inválido (%q)", s)
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	// Casas além dos centavos só são aceitas se forem zeros (ex: "10.500" em OFX)
+	if len(fracPart) > 2 {
+		if strings.Trim(fracPart[2:], "0") != "" {
+			return 0, fmt.Errorf("valor inválido (%q): mais de duas casas decimais", s)
+		}
+		fracPart = fracPart[:2]
+	}
+	fracPart += strings.Repeat("0", 2-len(fracPart))
+
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, fmt.Errorf("valor inválido (%q)", s)
+	}
+
+	reais, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || reais > math.MaxInt64/100-1 {
+		return 0, fmt.Errorf("valor inválido (%q): fora do intervalo suportado", s)
+	}
+	centavos, _ := strconv.ParseInt(fracPart, 10, 64)
+
+	value := Centavos(reais*100 + centavos)
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Abs retorna o valor absoluto
+func (c Centavos) Abs() Centavos {
+	if c < 0 {
+		return -c
+	}
+	return c
+}
+
+// String formata o valor com ponto decimal e duas casas ("-1234.56"), formato usado no banco de dados
+func (c Centavos) String() string {
+	sign := ""
+	if c < 0 {
+		sign = "-"
+	}
+	abs := uint64(c.Abs())
+	return fmt.Sprintf("%s%d.%02d", sign, abs/100, abs%100)
+}
+
+// FormatBR formata o valor no padrão brasileiro ("-1.234,56")
+func (c Centavos) FormatBR() string {
+	sign := ""
+	if c < 0 {
+		sign = "-"
+	}
+	abs := uint64(c.Abs())
+
+	reais := strconv.FormatUint(abs/100, 10)
+	var b strings.Builder
+	for i, r := range reais {
+		if i > 0 && (len(reais)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+
+	return fmt.Sprintf("%s%s,%02d", sign, b.String(), abs%100)
+}
+
+// MarshalJSON escreve o valor como número decimal em reais (-1234.56)
+func (c Centavos) MarshalJSON() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalJSON lê valores escritos por MarshalJSON
+func (c *Centavos) UnmarshalJSON(data []byte) error {
+	return c.scanString(strings.Trim(string(data), `"`))
+}
+
+// Value implementa driver.Valuer, enviando o valor como NUMERIC
+func (c Centavos) Value() (driver.Value, error) {
+	return c.String(), nil
+}
+
+// Scan implementa sql.Scanner, lendo colunas NUMERIC(14,2)
+func (c *Centavos) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return c.scanString(string(v))
+	case string:
+		return c.scanString(v)
+	case int64:
+		*c = Centavos(v * 100)
+		return nil
+	case float64:
+		*c = Centavos(math.Round(v * 100))
+		return nil
+	case nil:
+		*c = 0
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into money.Centavos", src)
+	}
+}
+
+func (c *Centavos) scanString(s string) error {
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*c = v
+	return nil
+}
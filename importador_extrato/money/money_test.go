@@ -0,0 +1,154 @@
+package money
+
+import "testing"
+
+func TestParseBR(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Centavos
+		wantErr bool
+	}{
+		{"1234,56", 123456, false},
+		{"1.234,56", 123456, false},
+		{"R$ 1.234.567,89", 123456789, false},
+		{"R$ 1.234,56", 123456, false},
+		{"-1.234,56", -123456, false},
+		{"R$ -1.234,56", -123456, false},
+		{"+10,00", 1000, false},
+		{"1.234,56-", -123456, false},
+		{"1.234,56 D", -123456, false},
+		{"1.234,56 C", 123456, false},
+		{"1234,5", 123450, false},
+		{"1234", 123400, false},
+		{",5", 50, false},
+		{"10,500", 1050, false},
+		{"0,00", 0, false},
+
+		{"10,505", 0, true},
+		{"", 0, true},
+		{"-", 0, true},
+		{"D", 0, true},
+		{"R$", 0, true},
+		{"abc", 0, true},
+		{"1,2,3", 0, true},
+		{"12a,00", 0, true},
+		{"-10,00 D", 0, true},
+		{"99999999999999999999,00", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBR(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseBR(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBR(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Centavos
+		wantErr bool
+	}{
+		{"1234.56", 123456, false},
+		{"1,234.56", 123456, false},
+		{"-1234.56", -123456, false},
+		{"50", 5000, false},
+		{"50.5", 5050, false},
+		{"10.500", 1050, false},
+		{"-.5", -50, false},
+
+		{"10.505", 0, true},
+		{"", 0, true},
+		{"1.2.3", 0, true},
+		{"1e3", 0, true},
+		// Sinal depois do valor só no formato brasileiro
+		{"50D", 0, true},
+		{"50-", 0, true},
+		{"50.00 C", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDecimal(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDecimal(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		in   Centavos
+		str  string
+		br   string
+		json string
+	}{
+		{0, "0.00", "0,00", "0.00"},
+		{5, "0.05", "0,05", "0.05"},
+		{-5, "-0.05", "-0,05", "-0.05"},
+		{123456, "1234.56", "1.234,56", "1234.56"},
+		{-123456, "-1234.56", "-1.234,56", "-1234.56"},
+		{123456789, "1234567.89", "1.234.567,89", "1234567.89"},
+		{100000, "1000.00", "1.000,00", "1000.00"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.str {
+			t.Errorf("Centavos(%d).String() = %q, want %q", tt.in, got, tt.str)
+		}
+		if got := tt.in.FormatBR(); got != tt.br {
+			t.Errorf("Centavos(%d).FormatBR() = %q, want %q", tt.in, got, tt.br)
+		}
+		got, err := tt.in.MarshalJSON()
+		if err != nil || string(got) != tt.json {
+			t.Errorf("Centavos(%d).MarshalJSON() = %s, %v, want %s", tt.in, got, err, tt.json)
+		}
+
+		var back Centavos
+		if err := back.UnmarshalJSON(got); err != nil || back != tt.in {
+			t.Errorf("UnmarshalJSON(%s) = %d, %v, want %d", got, back, err, tt.in)
+		}
+	}
+}
+
+func TestValueScan(t *testing.T) {
+	v, err := Centavos(-123456).Value()
+	if err != nil || v != "-1234.56" {
+		t.Errorf("Value() = %v, %v, want -1234.56", v, err)
+	}
+
+	tests := []struct {
+		src     any
+		want    Centavos
+		wantErr bool
+	}{
+		{[]byte("1234.56"), 123456, false},
+		{[]byte("-0.01"), -1, false},
+		{"1234.56", 123456, false},
+		{"-1234.5", -123450, false},
+		{int64(12), 1200, false},
+		{int64(-3), -300, false},
+		{float64(12.34), 1234, false},
+		{nil, 0, false},
+
+		{[]byte("abc"), 0, true},
+		{"1,234.567", 0, true},
+		{true, 0, true},
+	}
+	for _, tt := range tests {
+		c := Centavos(99)
+		err := c.Scan(tt.src)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Scan(%#v) error = %v, wantErr %v", tt.src, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && c != tt.want {
+			t.Errorf("Scan(%#v) = %d, want %d", tt.src, c, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"log/slog"
+	"slices"
+)
+
+// checkRunningBalance confere o saldo informado em cada transação: deve ser o saldo da transação
+// anterior mais o valor. O saldo da última transação deve ser o saldo do cabeçalho do extrato
+// (headerLine é a linha do cabeçalho). Cada divergência gera um diagnóstico SeverityWarning, o que
+// aponta exportações truncadas ou linhas perdidas pelo parser e, em modo estrito, rejeita o arquivo.
+//
+// O Inter exporta da transação mais recente para a mais antiga, mas arquivos em ordem cronológica
+// também são aceitos: vale a ordem em que os saldos fecham.
+func (s *Statement) checkRunningBalance(logger *slog.Logger, headerLine int) {
+	if len(s.Transactions) == 0 {
+		return
+	}
+
+	chronological, mismatches := s.chronologicalTransactions()
+
+	for _, i := range mismatches {
+		prev, t := chronological[i-1], chronological[i]
+		s.addDiagnostic(logger, SeverityWarning, t.Line, "",
+			"running balance mismatch: balance %s at line %d plus amount %s is %s, but the row shows %s (missing or altered row?)",
+			prev.Balance, prev.Line, t.Amount, prev.Balance+t.Amount, t.Balance)
+	}
+
+	last := chronological[len(chronological)-1]
+	if last.Balance != s.Balance {
+		s.addDiagnostic(logger, SeverityWarning, headerLine, "",
+			"statement balance %s does not match the balance %s of the last transaction (line %d)",
+			s.Balance, last.Balance, last.Line)
+	}
+}
+
+// chronologicalTransactions retorna as transações em ordem cronológica, junto com as divergências
+// de saldo nessa ordem (veja runningBalanceMismatches). Na dúvida entre a ordem do arquivo e a
+// inversa, vale a que tiver menos divergências e, no empate, a das datas.
+func (s *Statement) chronologicalTransactions() ([]Transaction, []int) {
+	reversed := slices.Clone(s.Transactions)
+	slices.Reverse(reversed)
+
+	asListed, mismatches := runningBalanceMismatches(s.Transactions), runningBalanceMismatches(reversed)
+	if len(asListed) < len(mismatches) ||
+		(len(asListed) == len(mismatches) && !s.Transactions[0].Date.After(s.Transactions[len(s.Transactions)-1].Date)) {
+		return s.Transactions, asListed
+	}
+	return reversed, mismatches
+}
+
+// setDailyBalances preenche Statement.DailyBalances com o saldo da última transação de cada dia,
+// para extratos que informam o saldo após cada transação
+func (s *Statement) setDailyBalances() {
+	if len(s.Transactions) == 0 {
+		return
+	}
+
+	chronological, _ := s.chronologicalTransactions()
+	for _, t := range chronological {
+		if n := len(s.DailyBalances); n > 0 && s.DailyBalances[n-1].Date.Equal(t.Date) {
+			s.DailyBalances[n-1].Balance = t.Balance
+			continue
+		}
+		s.DailyBalances = append(s.DailyBalances, DailyBalance{Date: t.Date, Balance: t.Balance})
+	}
+}
+
+// runningBalanceMismatches retorna os índices das transações, na ordem informada, cujo saldo não
+// é o saldo da anterior mais o valor. A comparação é sempre com o saldo informado na anterior, para
+// que uma única linha faltando gere uma única divergência.
+func runningBalanceMismatches(transactions []Transaction) []int {
+	var mismatches []int
+	for i := 1; i < len(transactions); i++ {
+		if transactions[i-1].Balance+transactions[i].Amount != transactions[i].Balance {
+			mismatches = append(mismatches, i)
+		}
+	}
+	return mismatches
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Counterparty é a outra parte de uma transferência ou pagamento, como descrita pelo banco
+type Counterparty struct {
+	Name     string
+	Document string // CPF ou CNPJ só com dígitos; os dígitos ocultos de um CPF mascarado ficam como '*'
+	BankName string
+	BankCode string // código do banco (COMPE), ex: "260"
+	ISPB     string // identificador do banco no Pix, quando o banco informa só ele (Inter)
+	Agency   string
+	Account  string
+}
+
+// nubankCounterpartyRegex separa o complemento das transferências do Nubank (tudo após o primeiro " - "):
+// "NOME - CPF/CNPJ - BANCO (CODIGO) Agência: X Conta: Y". O nome do banco pode ter parênteses,
+// como "BCO SANTANDER (BRASIL) S.A. (0033)"; o código é o último.
+var nubankCounterpartyRegex = regexp.MustCompile(
+	`^(?P<name>.+?) - (?P<document>[\d•*][\d•*./-]{10,17}) - (?P<bank>.+?)(?: \((?P<code>\d{1,5})\))?` +
+		`(?:\s+Ag[êe]ncia:\s*(?P<agency>\S+))?(?:\s+Conta:\s*(?P<account>\S+))?\s*$`)
+
+// nubankCounterparty extrai a contraparte do complemento da descrição do Nubank (veja
+// extractNubankDescriptionAndDetails). Complementos sem documento, como o estabelecimento de
+// "Pagamento - ESTABELECIMENTO", viram uma contraparte só com o nome. Retorna nil sem complemento.
+func nubankCounterparty(details string) *Counterparty {
+	details = strings.TrimSpace(details)
+	if details == "" {
+		return nil
+	}
+
+	m := nubankCounterpartyRegex.FindStringSubmatch(details)
+	if m == nil {
+		if strings.Contains(details, " - ") {
+			return nil
+		}
+		return &Counterparty{Name: details}
+	}
+
+	group := func(name string) string {
+		return strings.TrimSpace(m[nubankCounterpartyRegex.SubexpIndex(name)])
+	}
+	return &Counterparty{
+		Name:     group("name"),
+		Document: normalizeDocument(group("document")),
+		BankName: group("bank"),
+		BankCode: group("code"),
+		Agency:   group("agency"),
+		Account:  group("account"),
+	}
+}
+
+// interTransferRegex identifica os históricos do Inter cuja descrição é a contraparte
+var interTransferRegex = regexp.MustCompile(`(?i)\b(pix|ted|doc|transfer[êe]ncia)\b`)
+
+// interCounterpartyRegex lê a descrição das transferências do Inter: "Cp :<ISPB>-<NOME>",
+// ou só o nome nas exportações antigas, às vezes seguido do CPF/CNPJ
+var interCounterpartyRegex = regexp.MustCompile(
+	`^(?:Cp\s*:\s*(?P<ispb>\d{8})\s*-\s*)?(?P<name>.+?)(?:\s*-?\s*(?P<document>\d{2}\.\d{3}\.\d{3}/\d{4}-\d{2}|[\d•*]{3}\.[\d•*]{3}\.[\d•*]{3}-[\d•*]{2}))?\s*$`)
+
+// interCounterparty extrai a contraparte da descrição de uma transferência do Inter.
+// Retorna nil para os demais históricos (pagamentos, tarifas), cuja descrição não é a contraparte.
+func interCounterparty(history, description string) *Counterparty {
+	description = strings.Trim(strings.TrimSpace(description), `"`)
+	if description == "" || !interTransferRegex.MatchString(history) {
+		return nil
+	}
+
+	m := interCounterpartyRegex.FindStringSubmatch(description)
+	if m == nil {
+		return nil
+	}
+	group := func(name string) string {
+		return strings.TrimSpace(m[interCounterpartyRegex.SubexpIndex(name)])
+	}
+	return &Counterparty{
+		Name:     group("name"),
+		Document: normalizeDocument(group("document")),
+		ISPB:     group("ispb"),
+	}
+}
+
+// normalizeDocument remove a máscara do CPF/CNPJ, mantendo os dígitos ocultos como '*'
+func normalizeDocument(document string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9':
+			return r
+		case r == '•' || r == '*':
+			return '*'
+		}
+		return -1
+	}, document)
+}
@@ -0,0 +1,47 @@
+package parser
+
+import "testing"
+
+func TestNubankCounterparty(t *testing.T) {
+	tests := []struct {
+		details string
+		want    *Counterparty
+	}{
+		{
+			"FULANO DE TAL - •••.456.789-•• - BCO SANTANDER (BRASIL) S.A. (0033) Agência: 1234 Conta: 12345678-9",
+			&Counterparty{Name: "FULANO DE TAL", Document: "***456789**", BankName: "BCO SANTANDER (BRASIL) S.A.", BankCode: "0033", Agency: "1234", Account: "12345678-9"},
+		},
+		{
+			"EMPRESA EXEMPLO LTDA - 11.222.333/0001-81 - ITAÚ UNIBANCO S.A. (0341) Agência: 1 Conta: 2-3",
+			&Counterparty{Name: "EMPRESA EXEMPLO LTDA", Document: "11222333000181", BankName: "ITAÚ UNIBANCO S.A.", BankCode: "0341", Agency: "1", Account: "2-3"},
+		},
+		{"ESTABELECIMENTO EXEMPLO", &Counterparty{Name: "ESTABELECIMENTO EXEMPLO"}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		got := nubankCounterparty(tt.details)
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("nubankCounterparty(%q) = %+v, want %+v", tt.details, got, tt.want)
+		}
+	}
+}
+
+func TestInterCounterparty(t *testing.T) {
+	tests := []struct {
+		history, description string
+		want                 *Counterparty
+	}{
+		{"Pix recebido", `"Cp :18236120-FULANO DE TAL"`, &Counterparty{Name: "FULANO DE TAL", ISPB: "18236120"}},
+		{"Pix enviado ", "Empresa Exemplo Ltda 11.222.333/0001-81", &Counterparty{Name: "Empresa Exemplo Ltda", Document: "11222333000181"}},
+		{"TED Recebida", "Cliente Exemplo SA", &Counterparty{Name: "Cliente Exemplo SA"}},
+		{"Pagamento efetuado", "Pagamento Fatura Cartão", nil},
+	}
+
+	for _, tt := range tests {
+		got := interCounterparty(tt.history, tt.description)
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("interCounterparty(%q, %q) = %+v, want %+v", tt.history, tt.description, got, tt.want)
+		}
+	}
+}
@@ -2,21 +2,20 @@ package parser
 
 import (
 	"fmt"
+	"log/slog"
 	"path/filepath"
 	"regexp"
-	"strings"
-
-	"github.com/ledongthuc/pdf"
 )
 
 // DasSimplesNacionalParser é o parser para documentos DAS do Simples Nacional em formato PDF
 type DasSimplesNacionalParser struct {
 	parser *FiscalParser
+	logger *slog.Logger
 }
 
 // NewDasSimplesNacionalParser cria uma nova instância do parser do Simples Nacional
-func NewDasSimplesNacionalParser() *DasSimplesNacionalParser {
-	return &DasSimplesNacionalParser{parser: NewFiscalParser()}
+func NewDasSimplesNacionalParser(logger *slog.Logger) *DasSimplesNacionalParser {
+	return &DasSimplesNacionalParser{parser: NewFiscalParser(), logger: loggerOrDefault(logger)}
 }
 
 // GetName retorna o nome do parser
@@ -24,54 +23,37 @@ func (p *DasSimplesNacionalParser) GetName() string {
 	return "Das Simples Nacional"
 }
 
-// CanParse verifica se o arquivo pode ser processado por este parser
-func (p *DasSimplesNacionalParser) CanParse(filename string) bool {
-	// Verifica se o arquivo está na pasta simples_nacional e é um PDF
-	ext := strings.ToLower(filepath.Ext(filename))
-	if ext != ".pdf" {
-		return false
-	}
+// dasMarkers são os indícios de um DAS (guia de pagamento) do Simples Nacional
+var dasMarkers = []marker{
+	{description: "arquivo PDF", weight: 0.1, match: func(s *Sample) bool { return s.IsPDF }},
+	textMarker("texto 'Documento de Arrecadação do Simples Nacional'", 0.4, `(?i)Documento\s*de\s*Arrecada[çc][ãa]o\s*do\s*Simples\s*Nacional`),
+	textMarker("número do documento no formato 00.00.00000.0000000-0", 0.3, `\d{2}\.\d{2}\.\d{5}\.\d{7}-\d`),
+	textMarker("período de apuração por extenso (ex: Outubro/2025)", 0.2, `(?:Janeiro|Fevereiro|Mar[çc]o|Abril|Maio|Junho|Julho|Agosto|Setembro|Outubro|Novembro|Dezembro)/\d{4}`),
+}
 
-	// Verifica se está na pasta simples_nacional
-	return strings.Contains(filepath.ToSlash(filename), "/extrato/das/")
+// Detect verifica se o conteúdo é um DAS do Simples Nacional
+func (p *DasSimplesNacionalParser) Detect(sample *Sample) Detection {
+	return detectMarkers(sample, "das", dasMarkers)
 }
 
 // Parse processa um arquivo PDF do Simples Nacional (DAS)
-func (p *DasSimplesNacionalParser) Parse(filename string) (*Statement, error) {
-	// Abre o PDF
-	f, r, err := pdf.Open(filename)
+func (p *DasSimplesNacionalParser) Parse(in *Input) (*Statement, error) {
+	logger := p.logger.With("file", filepath.Base(in.Filename), "parser", p.GetName())
+
+	// Extrai texto de todas as páginas
+	text, pageErrors, err := extractPDFText(in)
 	if err != nil {
-		return nil, fmt.Errorf("error opening PDF: %v", err)
+		return nil, err
 	}
-	defer f.Close()
 
 	stmt := &Statement{
 		AccountNumber: "das-simples-nacional", // Identificador especial para Simples Nacional
 		Transactions:  []Transaction{},
 	}
-
-	var fullText strings.Builder
-
-	// Extrai texto de todas as páginas
-	totalPages := r.NumPage()
-	for pageNum := 1; pageNum <= totalPages; pageNum++ {
-		page := r.Page(pageNum)
-		if page.V.IsNull() {
-			continue
-		}
-
-		text, err := page.GetPlainText(nil)
-		if err != nil {
-			fmt.Printf("Warning: error reading page %d: %v\n", pageNum, err)
-			continue
-		}
-
-		fullText.WriteString(text)
-		fullText.WriteString("\n")
+	for _, pageErr := range pageErrors {
+		stmt.addDiagnostic(logger, SeverityWarning, 0, "", "%v - page skipped", pageErr)
 	}
 
-	text := fullText.String()
-
 	// Parse das informações do DAS
 	dasDocumento, err := p.extractDASTransactions(text)
 	if err != nil {
@@ -0,0 +1,164 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// sampleSize é a quantidade de bytes lida de arquivos texto para a detecção
+const sampleSize = 64 * 1024
+
+// Sample contém o conteúdo de um arquivo usado na detecção do parser
+type Sample struct {
+	Filename string
+	IsPDF    bool
+	Text     string // texto extraído do PDF ou início do arquivo texto, em UTF-8
+}
+
+// NewSample lê o início do arquivo em disco (ou o texto completo, no caso de PDFs) para detecção
+func NewSample(filename string) (*Sample, error) {
+	in, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	return ReadSample(in)
+}
+
+// ReadSample lê o início do conteúdo (ou o texto completo, no caso de PDFs) para detecção,
+// sem consumir o Input, que depois é lido pelo parser escolhido
+func ReadSample(in *Input) (*Sample, error) {
+	head, err := in.peek(sampleSize)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := &Sample{Filename: in.Filename}
+
+	if bytes.HasPrefix(head, []byte("%PDF-")) {
+		sample.IsPDF = true
+		// Páginas ilegíveis só importam na leitura do arquivo pelo parser
+		sample.Text, _, err = extractPDFText(in)
+		if err != nil {
+			return nil, err
+		}
+		return sample, nil
+	}
+
+	sample.Text = decodeText(head)
+	return sample, nil
+}
+
+// decodeText converte o conteúdo para UTF-8, tratando BOM e arquivos em ISO-8859-1
+func decodeText(content []byte) string {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	if utf8.Valid(content) {
+		return string(content)
+	}
+
+	// Exportações antigas de bancos costumam vir em ISO-8859-1
+	runes := make([]rune, len(content))
+	for i, b := range content {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// Ext retorna a extensão do arquivo em minúsculas
+func (s *Sample) Ext() string {
+	return strings.ToLower(filepath.Ext(s.Filename))
+}
+
+// InFolder verifica se o arquivo está dentro de rawdata/extrato/<folder>
+func (s *Sample) InFolder(folder string) bool {
+	return strings.Contains(filepath.ToSlash(s.Filename), "/extrato/"+folder+"/")
+}
+
+// Detection é o resultado da análise de um arquivo por um parser
+type Detection struct {
+	Confidence  float64  // 0 (não reconhecido) a 1 (certeza)
+	Evidence    []string // marcadores encontrados no arquivo
+	LookedFor   []string // todos os marcadores procurados pelo parser
+	FolderMatch bool     // arquivo está na pasta esperada pelo parser (usado só como desempate)
+}
+
+// marker é um indício de formato procurado no conteúdo do arquivo
+type marker struct {
+	description string
+	weight      float64
+	match       func(s *Sample) bool
+}
+
+// textMarker cria um marker que procura uma expressão regular no texto do arquivo
+func textMarker(description string, weight float64, pattern string) marker {
+	re := regexp.MustCompile(pattern)
+	return marker{
+		description: description,
+		weight:      weight,
+		match:       func(s *Sample) bool { return re.MatchString(s.Text) },
+	}
+}
+
+// detectMarkers soma os pesos dos markers encontrados no arquivo
+func detectMarkers(s *Sample, folder string, markers []marker) Detection {
+	d := Detection{FolderMatch: s.InFolder(folder)}
+
+	for _, m := range markers {
+		d.LookedFor = append(d.LookedFor, m.description)
+		if m.match(s) {
+			d.Confidence += m.weight
+			d.Evidence = append(d.Evidence, m.description)
+		}
+	}
+
+	if d.Confidence > 1 {
+		d.Confidence = 1
+	}
+
+	return d
+}
+
+// Match é o parser escolhido para um arquivo e a confiança da escolha
+type Match struct {
+	Parser     Parser
+	Detection  Detection
+	Candidates []Candidate
+}
+
+// Candidate é o resultado da detecção de um parser para um arquivo
+type Candidate struct {
+	ParserName string
+	Detection  Detection
+
+	parser Parser
+}
+
+// DetectionError descreve por que nenhum parser foi escolhido para um arquivo
+type DetectionError struct {
+	Filename   string
+	Reason     string
+	Candidates []Candidate
+}
+
+func (e *DetectionError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s for file: %s", e.Reason, filepath.Base(e.Filename))
+	for _, c := range e.Candidates {
+		fmt.Fprintf(&b, "\n  - %s: confidence %.2f", c.ParserName, c.Detection.Confidence)
+		if c.Detection.FolderMatch {
+			b.WriteString(" (folder match)")
+		}
+		fmt.Fprintf(&b, "\n      looked for: %s", strings.Join(c.Detection.LookedFor, "; "))
+		found := "nothing"
+		if len(c.Detection.Evidence) > 0 {
+			found = strings.Join(c.Detection.Evidence, "; ")
+		}
+		fmt.Fprintf(&b, "\n      found: %s", found)
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// Severity é a gravidade de um diagnóstico
+type Severity string
+
+const (
+	// SeverityInfo registra algo que merece atenção, mas não altera o extrato (ex: linha sem efeito)
+	SeverityInfo Severity = "info"
+	// SeverityWarning indica que o extrato foi lido, mas parte da informação pode estar incompleta (ex: página de PDF ilegível)
+	SeverityWarning Severity = "warning"
+	// SeverityError indica uma linha do arquivo que não pôde ser lida e ficou fora do extrato
+	SeverityError Severity = "error"
+)
+
+// Diagnostic descreve um problema em um registro do arquivo.
+//
+// Todos os parsers seguem a mesma política: um registro ilegível (data, valor ou
+// quantidade de colunas inválidos) é deixado de fora do extrato e gera um diagnóstico
+// SeverityError, sem interromper a leitura do restante do arquivo. Só problemas que
+// impedem entender o arquivo como um todo (cabeçalho, conta, formato) retornam erro em Parse.
+// Transações de valor zero também ficam de fora, com um diagnóstico SeverityInfo (veja addTransaction).
+// Em modo estrito (veja Statement.StrictError) qualquer diagnóstico a partir de
+// SeverityWarning invalida o arquivo inteiro.
+type Diagnostic struct {
+	Line     int    // linha do arquivo (0 quando o formato não tem linhas, como PDF)
+	Record   string // conteúdo original do registro
+	Reason   string
+	Severity Severity
+}
+
+func (d Diagnostic) String() string {
+	if d.Line > 0 {
+		return fmt.Sprintf("line %d: %s (%s)", d.Line, d.Reason, d.Severity)
+	}
+	return fmt.Sprintf("%s (%s)", d.Reason, d.Severity)
+}
+
+// addDiagnostic registra um diagnóstico no extrato e o envia ao log em nível debug;
+// quem importa o extrato decide como apresentá-lo
+func (s *Statement) addDiagnostic(logger *slog.Logger, severity Severity, line int, record string, format string, args ...any) {
+	d := Diagnostic{
+		Line:     line,
+		Record:   record,
+		Reason:   fmt.Sprintf(format, args...),
+		Severity: severity,
+	}
+	s.Diagnostics = append(s.Diagnostics, d)
+	logger.Debug("parser diagnostic", "line", d.Line, "severity", d.Severity, "reason", d.Reason, "record", d.Record)
+}
+
+// addTransaction inclui a transação lida no extrato. Transações de valor zero (autorizações de
+// cartão, estornos de R$ 0,00, linhas informativas) não movimentam a conta e não podem ser gravadas
+// (CHECK valor > 0 em financeiro.transacoes): ficam fora do extrato com um diagnóstico SeverityInfo.
+func (s *Statement) addTransaction(logger *slog.Logger, t Transaction, record string) {
+	if t.Amount == 0 {
+		s.addDiagnostic(logger, SeverityInfo, t.Line, record, "zero-value transaction - row skipped")
+		return
+	}
+	s.Transactions = append(s.Transactions, t)
+}
+
+// rowError registra uma linha que ficou fora do extrato
+func (s *Statement) rowError(logger *slog.Logger, line int, record string, format string, args ...any) {
+	s.addDiagnostic(logger, SeverityError, line, record, format+" - row skipped", args...)
+}
+
+// StrictError retorna erro se o extrato tiver algum diagnóstico de severidade warning ou error,
+// para quem precisa garantir que todas as linhas do arquivo foram lidas
+func (s *Statement) StrictError() error {
+	var count int
+	var first *Diagnostic
+	for i, d := range s.Diagnostics {
+		if d.Severity == SeverityInfo {
+			continue
+		}
+		if first == nil {
+			first = &s.Diagnostics[i]
+		}
+		count++
+	}
+
+	if count == 0 {
+		return nil
+	}
+	return fmt.Errorf("strict mode: %d row diagnostic(s), first at %s", count, first)
+}
@@ -2,22 +2,21 @@ package parser
 
 import (
 	"fmt"
-	"os"
+	"log/slog"
 	"path/filepath"
 	"regexp"
 	"strings"
-
-	"github.com/ledongthuc/pdf"
 )
 
 // ExtratoSimplesNacionalParser é o parser para documentos DAS do Simples Nacional em formato PDF
 type ExtratoSimplesNacionalParser struct {
 	parser *FiscalParser
+	logger *slog.Logger
 }
 
 // NewExtratoSimplesNacionalParser cria uma nova instância do parser do Simples Nacional
-func NewExtratoSimplesNacionalParser() *ExtratoSimplesNacionalParser {
-	return &ExtratoSimplesNacionalParser{parser: NewFiscalParser()}
+func NewExtratoSimplesNacionalParser(logger *slog.Logger) *ExtratoSimplesNacionalParser {
+	return &ExtratoSimplesNacionalParser{parser: NewFiscalParser(), logger: loggerOrDefault(logger)}
 }
 
 // GetName retorna o nome do parser
@@ -25,54 +24,38 @@ func (p *ExtratoSimplesNacionalParser) GetName() string {
 	return "Extrato Simples Nacional"
 }
 
-// CanParse verifica se o arquivo pode ser processado por este parser
-func (p *ExtratoSimplesNacionalParser) CanParse(filename string) bool {
-	// Verifica se o arquivo está na pasta simples_nacional e é um PDF
-	ext := strings.ToLower(filepath.Ext(filename))
-	if ext != ".pdf" {
-		return false
-	}
+// extratoSimplesNacionalMarkers são os indícios de um extrato do DAS do Simples Nacional
+var extratoSimplesNacionalMarkers = []marker{
+	{description: "arquivo PDF", weight: 0.1, match: func(s *Sample) bool { return s.IsPDF }},
+	textMarker("texto 'Extrato'", 0.1, `(?i)Extrato`),
+	textMarker("campo 'Período de Apuração (PA): MM/AAAA'", 0.4, `(?i)Per[íi]odo\s*de\s*Apura[çc][ãa]o\s*\(PA\)\s*[:\s]*\d{2}/\d{4}`),
+	textMarker("campo 'Número: <17 dígitos>'", 0.3, `(?i)N[úu]mero\s*[:\s]*\d{17}`),
+	textMarker("quadro de composição com 'Principal'", 0.1, `(?i)Principal`),
+}
 
-	// Verifica se está na pasta simples_nacional
-	return strings.Contains(filepath.ToSlash(filename), "/extrato/extrato_simples_nacional/")
+// Detect verifica se o conteúdo é um extrato do DAS do Simples Nacional
+func (p *ExtratoSimplesNacionalParser) Detect(sample *Sample) Detection {
+	return detectMarkers(sample, "extrato_simples_nacional", extratoSimplesNacionalMarkers)
 }
 
 // Parse processa um arquivo PDF do Simples Nacional (DAS)
-func (p *ExtratoSimplesNacionalParser) Parse(filename string) (*Statement, error) {
-	// Abre o PDF
-	f, r, err := pdf.Open(filename)
+func (p *ExtratoSimplesNacionalParser) Parse(in *Input) (*Statement, error) {
+	logger := p.logger.With("file", filepath.Base(in.Filename), "parser", p.GetName())
+
+	// Extrai texto de todas as páginas
+	text, pageErrors, err := extractPDFText(in)
 	if err != nil {
-		return nil, fmt.Errorf("error opening PDF: %v", err)
+		return nil, err
 	}
-	defer f.Close()
 
 	stmt := &Statement{
 		AccountNumber: "extrato-simples-nacional", // Identificador especial para Extrato Simples Nacional
 		Transactions:  []Transaction{},
 	}
-
-	var fullText strings.Builder
-
-	// Extrai texto de todas as páginas
-	totalPages := r.NumPage()
-	for pageNum := 1; pageNum <= totalPages; pageNum++ {
-		page := r.Page(pageNum)
-		if page.V.IsNull() {
-			continue
-		}
-
-		text, err := page.GetPlainText(nil)
-		if err != nil {
-			fmt.Printf("Warning: error reading page %d: %v\n", pageNum, err)
-			continue
-		}
-
-		fullText.WriteString(text)
-		fullText.WriteString("\n")
+	for _, pageErr := range pageErrors {
+		stmt.addDiagnostic(logger, SeverityWarning, 0, "", "%v - page skipped", pageErr)
 	}
 
-	text := fullText.String()
-
 	// Parse das informações do DAS
 	dasDocumento, err := p.extractDASTransactions(text)
 	if err != nil {
@@ -88,8 +71,6 @@ func (p *ExtratoSimplesNacionalParser) Parse(filename string) (*Statement, error
 func (p *ExtratoSimplesNacionalParser) extractDASTransactions(texto string) (DasDocumento, error) {
 	var out DasDocumento
 
-	os.WriteFile("conteudo.txt", []byte(texto), 0644)
-
 	conteudo := cutAfterCaseInsensitive(texto, "principal")
 	if conteudo == "" {
 		conteudo = texto
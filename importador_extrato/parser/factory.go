@@ -2,35 +2,128 @@ package parser
 
 import (
 	"fmt"
+	"log/slog"
 	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// minConfidence é a confiança mínima para aceitar um parser
+	minConfidence = 0.5
+
+	// ambiguityMargin é a diferença mínima de confiança entre o melhor parser e o segundo colocado
+	ambiguityMargin = 0.15
 )
 
 // ParserFactory cria e retorna o parser apropriado para um arquivo
 type ParserFactory struct {
 	parsers []Parser
+	logger  *slog.Logger
 }
 
-// NewParserFactory cria uma nova instância da factory com todos os parsers disponíveis
-func NewParserFactory() *ParserFactory {
+// NewParserFactory cria uma nova instância da factory com todos os parsers disponíveis.
+// Com logger nil usa o logger padrão do slog.
+func NewParserFactory(logger *slog.Logger) *ParserFactory {
+	logger = loggerOrDefault(logger)
 	return &ParserFactory{
 		parsers: []Parser{
-			NewInterParser(),
-			NewNubankParser(),
-			NewDasSimplesNacionalParser(),
-			NewExtratoSimplesNacionalParser(),
+			NewInterParser(logger),
+			NewNubankParser(logger),
+			NewOFXParser(logger),
+			NewDasSimplesNacionalParser(logger),
+			NewExtratoSimplesNacionalParser(logger),
 		},
+		logger: logger,
 	}
 }
 
 // GetParser retorna o parser apropriado para o arquivo fornecido
 func (f *ParserFactory) GetParser(filename string) (Parser, error) {
+	match, err := f.Detect(filename)
+	if err != nil {
+		return nil, err
+	}
+	return match.Parser, nil
+}
+
+// Detect analisa o conteúdo do arquivo em disco com todos os parsers e retorna o de maior confiança
+func (f *ParserFactory) Detect(filename string) (*Match, error) {
+	in, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	return f.DetectInput(in)
+}
+
+// DetectInput analisa o conteúdo com todos os parsers e retorna o de maior confiança, sem consumir
+// o Input, que pode ser lido em seguida pelo parser escolhido.
+// A pasta do arquivo só é usada para desempatar parsers com confiança equivalente.
+func (f *ParserFactory) DetectInput(in *Input) (*Match, error) {
+	filename := in.Filename
+	sample, err := ReadSample(in)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := f.logger.With("file", filepath.Base(filename))
+
+	candidates := make([]Candidate, len(f.parsers))
+	for i, parser := range f.parsers {
+		candidates[i] = Candidate{ParserName: parser.GetName(), Detection: parser.Detect(sample), parser: parser}
+		logger.Debug("parser detection",
+			"parser", parser.GetName(),
+			"confidence", candidates[i].Detection.Confidence,
+			"folder_match", candidates[i].Detection.FolderMatch)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Detection.Confidence > candidates[j].Detection.Confidence
+	})
+
+	best := candidates[0]
+	if best.Detection.Confidence < minConfidence {
+		return nil, &DetectionError{Filename: filename, Reason: "no parser recognized the content", Candidates: candidates}
+	}
+
+	// Parsers com confiança próxima à do melhor são empates; a pasta decide
+	var tied []Candidate
+	for _, c := range candidates {
+		if best.Detection.Confidence-c.Detection.Confidence < ambiguityMargin {
+			tied = append(tied, c)
+		}
+	}
+
+	if len(tied) > 1 {
+		var inFolder []Candidate
+		for _, c := range tied {
+			if c.Detection.FolderMatch {
+				inFolder = append(inFolder, c)
+			}
+		}
+		if len(inFolder) != 1 {
+			return nil, &DetectionError{Filename: filename, Reason: "ambiguous content", Candidates: candidates}
+		}
+		best = inFolder[0]
+	}
+
+	return &Match{
+		Parser:     best.parser,
+		Detection:  best.Detection,
+		Candidates: candidates,
+	}, nil
+}
+
+// GetParserByName retorna o parser com o nome informado (sem diferenciar maiúsculas e minúsculas)
+func (f *ParserFactory) GetParserByName(name string) (Parser, error) {
 	for _, parser := range f.parsers {
-		if parser.CanParse(filename) {
+		if strings.EqualFold(parser.GetName(), strings.TrimSpace(name)) {
 			return parser, nil
 		}
 	}
-
-	return nil, fmt.Errorf("no parser found for file: %s", filepath.Base(filename))
+	return nil, fmt.Errorf("unknown parser %q (available: %s)", name, strings.Join(f.ListSupportedParsers(), ", "))
 }
 
 // GetAllParsers retorna todos os parsers disponíveis
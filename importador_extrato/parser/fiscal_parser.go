@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
 )
 
 // FiscalParser encapsula utilitários para parse de campos financeiros/fiscais brasileiros.
@@ -87,15 +89,7 @@ func (p *FiscalParser) ParsePeriodoNum(s string) (time.Time, error) {
 	return time.Date(ano, time.Month(mes), 1, 0, 0, 0, 0, p.Location), nil
 }
 
-// ParseValorBR converte "4.803,14" → 4803.14
-// Se quiser evitar float, retorne int64 (centavos).
-func (p *FiscalParser) ParseValorBR(s string) (float64, error) {
-	s = strings.TrimSpace(s)
-	s = strings.ReplaceAll(s, ".", "")
-	s = strings.ReplaceAll(s, ",", ".")
-	v, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return 0, fmt.Errorf("valor inválido (%q): %w", s, err)
-	}
-	return v, nil
+// ParseValorBR converte "4.803,14" → 480314 centavos
+func (p *FiscalParser) ParseValorBR(s string) (money.Centavos, error) {
+	return money.ParseBR(s)
 }
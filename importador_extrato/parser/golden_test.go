@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Para regenerar os arquivos golden depois de uma mudança intencional nos parsers:
+//
+//	go test ./parser -run TestGolden -update
+var update = flag.Bool("update", false, "regenera os arquivos .golden.json em testdata")
+
+// goldenResult é o conteúdo gravado no arquivo golden de cada fixture
+type goldenResult struct {
+	Parser    string     `json:"parser"`
+	Statement *Statement `json:"statement"`
+}
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		file   string
+		parser string
+	}{
+		{"inter/extrato_jan2025.csv", "Banco Inter"},
+		{"inter/linhas_invalidas.csv", "Banco Inter"},
+		{"inter/saldo_divergente.csv", "Banco Inter"},
+		{"inter/pix_repetido.csv", "Banco Inter"},
+		{"nubank/NU_1234567890_01JAN2025_31JAN2025.csv", "Nubank"},
+		{"nubank/NU_1234567890_01FEV2025_28FEV2025.csv", "Nubank"},
+		{"ofx/extrato_sgml.ofx", "OFX"},
+		{"ofx/extrato_xml.ofx", "OFX"},
+		{"das/das_102025.pdf", "Das Simples Nacional"},
+		{"extrato_simples_nacional/extrato_102025.pdf", "Extrato Simples Nacional"},
+	}
+
+	factory := NewParserFactory(nil)
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join("testdata", tt.file)
+
+			in, err := OpenInput(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer in.Close()
+
+			match, err := factory.DetectInput(in)
+			if err != nil {
+				t.Fatalf("detection failed: %v", err)
+			}
+			if got := match.Parser.GetName(); got != tt.parser {
+				t.Fatalf("detected parser %q, want %q", got, tt.parser)
+			}
+
+			stmt, err := match.Parser.Parse(in)
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(goldenResult{Parser: tt.parser, Statement: stmt}); err != nil {
+				t.Fatal(err)
+			}
+			got := buf.Bytes()
+
+			golden := strings.TrimSuffix(path, filepath.Ext(path)) + ".golden.json"
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("output differs from %s (run with -update if the change is intended)\ngot:\n%s", golden, got)
+			}
+		})
+	}
+}
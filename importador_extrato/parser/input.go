@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Input é o conteúdo a ser lido por um parser, com os metadados da origem.
+// O Reader pode vir de um arquivo em disco, de um upload HTTP, de um arquivo
+// dentro de um zip, de um anexo de e-mail ou de um fixture em memória.
+type Input struct {
+	Reader   io.Reader
+	Filename string // nome (ou caminho) original do arquivo: usado na detecção pela extensão e pasta, e pelo Nubank para a conta
+	Size     int64  // tamanho em bytes, ou -1 se desconhecido
+}
+
+// NewInput cria um Input com conteúdo em memória
+func NewInput(content []byte, filename string) *Input {
+	return &Input{Reader: bytes.NewReader(content), Filename: filename, Size: int64(len(content))}
+}
+
+// OpenInput abre um arquivo em disco como Input. O arquivo deve ser fechado com Close.
+func OpenInput(filename string) (*Input, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %v", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error opening file: %v", err)
+	}
+
+	return &Input{Reader: file, Filename: filename, Size: info.Size()}, nil
+}
+
+// Close fecha o Reader, se ele puder ser fechado
+func (in *Input) Close() error {
+	if closer, ok := in.Reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// peek retorna os primeiros n bytes sem consumi-los, para que o parser ainda leia o conteúdo desde o início.
+// Readers sem acesso aleatório passam a ser lidos através de um buffer.
+func (in *Input) peek(n int) ([]byte, error) {
+	if ra, ok := in.Reader.(io.ReaderAt); ok && in.Size >= 0 {
+		head := make([]byte, min(int64(n), in.Size))
+		read, err := ra.ReadAt(head, 0)
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("error reading file: %v", err)
+		}
+		return head[:read], nil
+	}
+
+	buffered, ok := in.Reader.(*bufio.Reader)
+	if !ok || buffered.Size() < n {
+		buffered = bufio.NewReaderSize(in.Reader, n)
+		in.Reader = buffered
+	}
+
+	head, err := buffered.Peek(n)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, fmt.Errorf("error reading file: %v", err)
+	}
+	return head, nil
+}
+
+// readerAt retorna o conteúdo com acesso aleatório, necessário para ler PDFs.
+// Se o Reader não permitir, o conteúdo é carregado em memória (e continua disponível para leituras seguintes).
+func (in *Input) readerAt() (io.ReaderAt, int64, error) {
+	if ra, ok := in.Reader.(io.ReaderAt); ok && in.Size >= 0 {
+		return ra, in.Size, nil
+	}
+
+	content, err := io.ReadAll(in.Reader)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading file: %v", err)
+	}
+
+	reader := bytes.NewReader(content)
+	in.Reader, in.Size = reader, int64(len(content))
+	return reader, in.Size, nil
+}
+
+// ParseFile lê um arquivo em disco com o parser informado
+func ParseFile(p Parser, filename string) (*Statement, error) {
+	in, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	return p.Parse(in)
+}
@@ -4,18 +4,22 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
-	"os"
+	"log/slog"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
 )
 
 // InterParser é o parser para extratos do Banco Inter em formato CSV
-type InterParser struct{}
+type InterParser struct {
+	logger *slog.Logger
+}
 
 // NewInterParser cria uma nova instância do parser do Inter
-func NewInterParser() *InterParser {
-	return &InterParser{}
+func NewInterParser(logger *slog.Logger) *InterParser {
+	return &InterParser{logger: loggerOrDefault(logger)}
 }
 
 // GetName retorna o nome do parser
@@ -23,34 +27,33 @@ func (p *InterParser) GetName() string {
 	return "Banco Inter"
 }
 
-// CanParse verifica se o arquivo pode ser processado por este parser
-func (p *InterParser) CanParse(filename string) bool {
-	// Verifica se o arquivo está na pasta inter e é um CSV
-	ext := strings.ToLower(filepath.Ext(filename))
-	if ext != ".csv" {
-		return false
-	}
+// interMarkers são os indícios de um extrato CSV do Banco Inter
+var interMarkers = []marker{
+	{description: "extensão .csv", weight: 0.1, match: func(s *Sample) bool { return !s.IsPDF && s.Ext() == ".csv" }},
+	textMarker("linha de título 'Extrato Conta Corrente'", 0.1, `(?im)^\s*Extrato\s+Conta\s+Corrente`),
+	textMarker("linha 'Conta ;<número>'", 0.2, `(?im)^\s*Conta\s*;\s*\d+`),
+	textMarker("linha 'Período ;<dd/mm/aaaa> a <dd/mm/aaaa>'", 0.1, `(?im)^\s*Per[íi]odo\s*;`),
+	textMarker("cabeçalho 'Data Lançamento;Histórico;Descrição;Valor;Saldo'", 0.5, `(?im)^\s*Data\s+Lan[çc]amento\s*;\s*Hist[óo]rico\s*;`),
+}
 
-	// Verifica se está na pasta inter
-	return strings.Contains(filepath.ToSlash(filename), "/extrato/inter/")
+// Detect verifica se o conteúdo é um extrato CSV do Banco Inter
+func (p *InterParser) Detect(sample *Sample) Detection {
+	return detectMarkers(sample, "inter", interMarkers)
 }
 
 // Parse processa um arquivo CSV do Banco Inter
-func (p *InterParser) Parse(filename string) (*Statement, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, fmt.Errorf("error opening file: %v", err)
-	}
-	defer file.Close()
+func (p *InterParser) Parse(in *Input) (*Statement, error) {
+	logger := p.logger.With("file", filepath.Base(in.Filename), "parser", p.GetName())
+	logger.Debug("parsing file")
 
-	reader := csv.NewReader(file)
+	reader := csv.NewReader(in.Reader)
 	reader.Comma = ';'
 	reader.FieldsPerRecord = -1
 	reader.TrimLeadingSpace = true
 	reader.LazyQuotes = true
 
 	// Skip header line
-	_, err = reader.Read()
+	_, err := reader.Read()
 	if err != nil {
 		return nil, fmt.Errorf("error reading header: %v", err)
 	}
@@ -75,7 +78,11 @@ func (p *InterParser) Parse(filename string) (*Statement, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error reading balance: %v", err)
 	}
-	stmt.Balance = parseAmount(balanceLine[1])
+	stmt.Balance, err = money.ParseBR(balanceLine[1])
+	if err != nil {
+		return nil, fmt.Errorf("error parsing balance: %v", err)
+	}
+	balanceLineNum, _ := reader.FieldPos(0)
 
 	// Skip column headers
 	_, err = reader.Read()
@@ -89,42 +96,58 @@ func (p *InterParser) Parse(filename string) (*Statement, error) {
 		if err == io.EOF {
 			break
 		}
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			stmt.rowError(logger, parseErr.StartLine, "", "malformed CSV row: %v", parseErr.Err)
+			continue
+		}
 		if err != nil {
 			return nil, fmt.Errorf("error reading transaction: %v", err)
 		}
+		line, _ := reader.FieldPos(0)
+		raw := strings.Join(record, ";")
+
+		// Formato do CSV do Inter: Data Lançamento; Histórico; Descrição; Valor; Saldo
+		if len(record) < 5 {
+			stmt.rowError(logger, line, raw, "expected 5 columns, found %d", len(record))
+			continue
+		}
 
 		date, err := parseDate(record[0])
 		if err != nil {
-			return nil, fmt.Errorf("error parsing date: %v", err)
+			stmt.rowError(logger, line, raw, "error parsing date: %v", err)
+			continue
+		}
+
+		amount, err := money.ParseBR(record[3])
+		if err != nil {
+			stmt.rowError(logger, line, raw, "error parsing amount: %v", err)
+			continue
+		}
+
+		balance, err := money.ParseBR(record[4])
+		if err != nil {
+			stmt.rowError(logger, line, raw, "error parsing balance: %v", err)
+			continue
 		}
 
 		transaction := Transaction{
-			Date:        date,
-			Description: strings.TrimSpace(record[1]),
-			Details:     strings.TrimSpace(record[2]),
-			Amount:      parseAmount(record[3]),
-			Balance:     parseAmount(record[4]),
+			Line:         line,
+			Date:         date,
+			Description:  strings.TrimSpace(record[1]),
+			Details:      strings.TrimSpace(record[2]),
+			Amount:       amount,
+			Balance:      balance,
+			Counterparty: interCounterparty(record[1], record[2]),
 		}
-		stmt.Transactions = append(stmt.Transactions, transaction)
+		stmt.addTransaction(logger, transaction, raw)
 	}
 
+	stmt.checkRunningBalance(logger, balanceLineNum)
+	stmt.setDailyBalances()
+
 	return stmt, nil
 }
 
 func parseDate(dateStr string) (time.Time, error) {
 	return time.Parse("02/01/2006", strings.TrimSpace(dateStr))
 }
-
-func parseAmount(amountStr string) float64 {
-	// Remove R$ and spaces
-	clean := strings.TrimSpace(strings.ReplaceAll(amountStr, "R$", ""))
-
-	// Replace comma with dot for decimal point
-	clean = strings.ReplaceAll(clean, ".", "")
-	clean = strings.ReplaceAll(clean, ",", ".")
-
-	// Convert to float
-	amount := 0.0
-	fmt.Sscanf(clean, "%f", &amount)
-	return amount
-}
@@ -4,18 +4,22 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
-	"os"
+	"log/slog"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
 )
 
 // NubankParser é o parser para extratos do Nubank em formato CSV
-type NubankParser struct{}
+type NubankParser struct {
+	logger *slog.Logger
+}
 
 // NewNubankParser cria uma nova instância do parser do Nubank
-func NewNubankParser() *NubankParser {
-	return &NubankParser{}
+func NewNubankParser(logger *slog.Logger) *NubankParser {
+	return &NubankParser{logger: loggerOrDefault(logger)}
 }
 
 // GetName retorna o nome do parser
@@ -23,33 +27,30 @@ func (p *NubankParser) GetName() string {
 	return "Nubank"
 }
 
-// CanParse verifica se o arquivo pode ser processado por este parser
-func (p *NubankParser) CanParse(filename string) bool {
-	// Verifica se o arquivo está na pasta nubank e é um CSV
-	ext := strings.ToLower(filepath.Ext(filename))
-	if ext != ".csv" {
-		return false
-	}
+// nubankMarkers são os indícios de um extrato CSV da conta Nubank
+var nubankMarkers = []marker{
+	{description: "extensão .csv", weight: 0.1, match: func(s *Sample) bool { return !s.IsPDF && s.Ext() == ".csv" }},
+	textMarker("cabeçalho 'Data,Valor,Identificador,Descrição'", 0.7, `(?im)^\s*Data\s*,\s*Valor\s*,\s*Identificador\s*,\s*Descri[çc][ãa]o`),
+	textMarker("linha com data, valor e identificador UUID separados por ','", 0.2, `(?m)^\s*\d{2}/\d{2}/\d{4}\s*,\s*-?\d+(\.\d+)?\s*,\s*[0-9a-f]{8}-[0-9a-f]{4}-`),
+}
 
-	// Verifica se está na pasta nubank
-	return strings.Contains(filepath.ToSlash(filename), "/extrato/nubank/")
+// Detect verifica se o conteúdo é um extrato CSV do Nubank
+func (p *NubankParser) Detect(sample *Sample) Detection {
+	return detectMarkers(sample, "nubank", nubankMarkers)
 }
 
 // Parse processa um arquivo CSV do Nubank
-func (p *NubankParser) Parse(filename string) (*Statement, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, fmt.Errorf("error opening file: %v", err)
-	}
-	defer file.Close()
+func (p *NubankParser) Parse(in *Input) (*Statement, error) {
+	logger := p.logger.With("file", filepath.Base(in.Filename), "parser", p.GetName())
+	logger.Debug("parsing file")
 
-	reader := csv.NewReader(file)
+	reader := csv.NewReader(in.Reader)
 	reader.Comma = ','
 	reader.FieldsPerRecord = -1
 	reader.TrimLeadingSpace = true
 
 	stmt := &Statement{
-		AccountNumber: extractAccountFromFilename(filename),
+		AccountNumber: extractAccountFromFilename(in.Filename),
 		Transactions:  []Transaction{},
 	}
 
@@ -70,22 +71,34 @@ func (p *NubankParser) Parse(filename string) (*Statement, error) {
 		if err == io.EOF {
 			break
 		}
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			stmt.rowError(logger, parseErr.StartLine, "", "malformed CSV row: %v", parseErr.Err)
+			continue
+		}
 		if err != nil {
 			return nil, fmt.Errorf("error reading transaction: %v", err)
 		}
+		line, _ := reader.FieldPos(0)
+		raw := strings.Join(record, ",")
 
 		// Formato do CSV do Nubank: Data, Valor, Identificador, Descrição
 		if len(record) < 4 {
+			stmt.rowError(logger, line, raw, "expected 4 columns, found %d", len(record))
 			continue
 		}
 
 		date, err := parseNubankDate(record[0])
 		if err != nil {
-			fmt.Printf("Warning: error parsing date '%s': %v - skipping transaction\n", record[0], err)
+			stmt.rowError(logger, line, raw, "error parsing date: %v", err)
+			continue
+		}
+
+		amount, err := money.ParseDecimal(record[1])
+		if err != nil {
+			stmt.rowError(logger, line, raw, "error parsing amount: %v", err)
 			continue
 		}
 
-		amount := parseNubankAmount(record[1])
 		identifier := strings.TrimSpace(record[2])
 		fullDescription := strings.TrimSpace(record[3])
 
@@ -93,12 +106,15 @@ func (p *NubankParser) Parse(filename string) (*Statement, error) {
 		description, details := extractNubankDescriptionAndDetails(fullDescription)
 
 		transaction := Transaction{
-			Date:        date,
-			Description: description,
-			Details:     fmt.Sprintf("ID: %s | %s", identifier, details),
-			Amount:      amount,
+			ID:           identifier,
+			Line:         line,
+			Date:         date,
+			Description:  description,
+			Details:      fmt.Sprintf("ID: %s | %s", identifier, details),
+			Amount:       amount,
+			Counterparty: nubankCounterparty(details),
 		}
-		stmt.Transactions = append(stmt.Transactions, transaction)
+		stmt.addTransaction(logger, transaction, raw)
 	}
 
 	return stmt, nil
@@ -124,24 +140,6 @@ func parseNubankDate(dateStr string) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("unable to parse date: %s", dateStr)
 }
 
-// parseNubankAmount converte string de valor para float64
-func parseNubankAmount(amountStr string) float64 {
-	// Remove espaços
-	clean := strings.TrimSpace(amountStr)
-
-	// Remove R$ se presente
-	clean = strings.ReplaceAll(clean, "R$", "")
-	clean = strings.TrimSpace(clean)
-
-	// Replace comma with dot for decimal point
-	clean = strings.ReplaceAll(clean, ",", ".")
-
-	// Convert to float
-	amount := 0.0
-	fmt.Sscanf(clean, "%f", &amount)
-	return amount
-}
-
 // extractAccountFromFilename extrai o número da conta do nome do arquivo
 func extractAccountFromFilename(filename string) string {
 	// Tenta extrair um padrão de conta do nome do arquivo
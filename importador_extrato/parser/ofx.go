@@ -0,0 +1,299 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
+)
+
+// OFXParser é o parser para extratos no formato OFX (Open Financial Exchange),
+// tanto na versão 1.x (SGML) quanto na 2.x (XML)
+type OFXParser struct {
+	logger *slog.Logger
+}
+
+// NewOFXParser cria uma nova instância do parser OFX
+func NewOFXParser(logger *slog.Logger) *OFXParser {
+	return &OFXParser{logger: loggerOrDefault(logger)}
+}
+
+// GetName retorna o nome do parser
+func (p *OFXParser) GetName() string {
+	return "OFX"
+}
+
+// ofxMarkers são os indícios de um arquivo OFX
+var ofxMarkers = []marker{
+	{description: "extensão .ofx", weight: 0.1, match: func(s *Sample) bool { return !s.IsPDF && s.Ext() == ".ofx" }},
+	textMarker("cabeçalho 'OFXHEADER:100' (SGML) ou '<?OFX ...?>' (XML)", 0.4, `(?i)OFXHEADER\s*:\s*100|<\?OFX\s`),
+	textMarker("elemento raiz <OFX>", 0.2, `(?i)<OFX>`),
+	textMarker("lista de transações <BANKTRANLIST> ou <STMTTRN>", 0.3, `(?i)<BANKTRANLIST>|<STMTTRN>`),
+}
+
+// Detect verifica se o conteúdo é um arquivo OFX
+func (p *OFXParser) Detect(sample *Sample) Detection {
+	return detectMarkers(sample, "ofx", ofxMarkers)
+}
+
+// Parse processa um arquivo OFX
+func (p *OFXParser) Parse(in *Input) (*Statement, error) {
+	logger := p.logger.With("file", filepath.Base(in.Filename), "parser", p.GetName())
+	logger.Debug("parsing file")
+
+	content, err := io.ReadAll(in.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %v", err)
+	}
+
+	elements, err := tokenizeOFX(decodeText(content))
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &Statement{Transactions: []Transaction{}}
+
+	var (
+		path           []string
+		current        *ofxTransaction
+		dtStart, dtEnd string
+		balance        string
+		balanceDate    string
+	)
+
+	for _, el := range elements {
+		switch el.kind {
+		case ofxStart:
+			path = append(path, el.name)
+			if el.name == "STMTTRN" {
+				current = &ofxTransaction{line: el.line}
+			}
+
+		case ofxEnd:
+			// Fecha até o agregado correspondente (SGML permite omitir fechamentos)
+			for i := len(path) - 1; i >= 0; i-- {
+				if path[i] == el.name {
+					path = path[:i]
+					break
+				}
+			}
+			if el.name == "STMTTRN" && current != nil {
+				tx, err := current.toTransaction()
+				if err != nil {
+					stmt.rowError(logger, current.line, current.raw(), "error parsing transaction: %v", err)
+				} else {
+					stmt.addTransaction(logger, tx, current.raw())
+				}
+				current = nil
+			}
+
+		case ofxLeaf:
+			parent := ""
+			if len(path) > 0 {
+				parent = path[len(path)-1]
+			}
+
+			switch {
+			case parent == "STMTTRN" && current != nil:
+				current.set(el.name, el.value)
+			case parent == "BANKACCTFROM" || parent == "CCACCTFROM":
+				switch el.name {
+				case "ACCTID":
+					stmt.AccountNumber = normalizeOFXAccount(el.value)
+				case "BRANCHID":
+					stmt.Agency = el.value
+				}
+			case parent == "BANKTRANLIST":
+				switch el.name {
+				case "DTSTART":
+					dtStart = el.value
+				case "DTEND":
+					dtEnd = el.value
+				}
+			case parent == "LEDGERBAL" && el.name == "BALAMT":
+				balance = el.value
+			case parent == "LEDGERBAL" && el.name == "DTASOF":
+				balanceDate = el.value
+			}
+		}
+	}
+
+	if stmt.AccountNumber == "" {
+		return nil, fmt.Errorf("account number (ACCTID) not found in OFX file")
+	}
+
+	if balance != "" {
+		stmt.Balance, err = parseOFXAmount(balance)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing ledger balance: %v", err)
+		}
+
+		// Saldo na data informada pelo banco; sem ela o saldo não é associado a um dia
+		if date, err := parseOFXDate(balanceDate); err == nil {
+			stmt.DailyBalances = []DailyBalance{{Date: date, Balance: stmt.Balance}}
+		} else {
+			stmt.addDiagnostic(logger, SeverityInfo, 0, balanceDate, "ledger balance without a valid DTASOF - not recorded as a daily balance")
+		}
+	}
+
+	start, errStart := parseOFXDate(dtStart)
+	end, errEnd := parseOFXDate(dtEnd)
+	if errStart == nil && errEnd == nil {
+		stmt.Period = fmt.Sprintf("%s a %s", start.Format("02/01/2006"), end.Format("02/01/2006"))
+	}
+
+	return stmt, nil
+}
+
+// ofxTransaction acumula os campos de um <STMTTRN>
+type ofxTransaction struct {
+	fitID, trnType, name, memo, dtPosted, amount string
+	line                                         int
+}
+
+func (t *ofxTransaction) set(name, value string) {
+	switch name {
+	case "FITID":
+		t.fitID = value
+	case "TRNTYPE":
+		t.trnType = value
+	case "NAME":
+		t.name = value
+	case "MEMO":
+		t.memo = value
+	case "DTPOSTED":
+		t.dtPosted = value
+	case "TRNAMT":
+		t.amount = value
+	}
+}
+
+// raw reconstrói os campos lidos do <STMTTRN>, para diagnósticos
+func (t *ofxTransaction) raw() string {
+	return fmt.Sprintf("<STMTTRN><TRNTYPE>%s<DTPOSTED>%s<TRNAMT>%s<FITID>%s<NAME>%s<MEMO>%s</STMTTRN>",
+		t.trnType, t.dtPosted, t.amount, t.fitID, t.name, t.memo)
+}
+
+func (t *ofxTransaction) toTransaction() (Transaction, error) {
+	date, err := parseOFXDate(t.dtPosted)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	amount, err := parseOFXAmount(t.amount)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	// Alguns bancos preenchem só o MEMO; nesse caso separa título e detalhes como no CSV do Nubank
+	description, details := t.name, t.memo
+	var counterparty *Counterparty
+	if description == "" {
+		description, details = extractNubankDescriptionAndDetails(t.memo)
+		counterparty = nubankCounterparty(details)
+	}
+	if description == "" {
+		description = t.trnType
+	}
+
+	return Transaction{
+		ID:           t.fitID,
+		Line:         t.line,
+		Date:         date,
+		Description:  description,
+		Details:      details,
+		Amount:       amount,
+		Counterparty: counterparty,
+	}, nil
+}
+
+type ofxElementKind int
+
+const (
+	ofxStart ofxElementKind = iota
+	ofxEnd
+	ofxLeaf
+)
+
+// ofxElement é um elemento do corpo OFX: abertura/fechamento de agregado ou campo com valor
+type ofxElement struct {
+	kind  ofxElementKind
+	name  string
+	value string
+	line  int // linha do arquivo onde está a tag
+}
+
+var ofxTagRegex = regexp.MustCompile(`<(/?)([A-Za-z0-9_.]+)>([^<]*)`)
+
+// tokenizeOFX transforma o corpo OFX em uma sequência de elementos.
+// Em SGML (OFX 1.x) os campos não têm tag de fechamento, então um campo é
+// qualquer tag seguida de texto; em XML (OFX 2.x) o fechamento do campo é ignorado.
+func tokenizeOFX(content string) ([]ofxElement, error) {
+	start := strings.Index(strings.ToUpper(content), "<OFX>")
+	if start == -1 {
+		return nil, fmt.Errorf("OFX body (<OFX>) not found")
+	}
+
+	var elements []ofxElement
+	lastLeaf := ""
+	line, offset := 1+strings.Count(content[:start], "\n"), start
+
+	for _, loc := range ofxTagRegex.FindAllStringSubmatchIndex(content[start:], -1) {
+		tagStart := start + loc[0]
+		line += strings.Count(content[offset:tagStart], "\n")
+		offset = tagStart
+
+		group := func(i int) string { return content[start+loc[2*i] : start+loc[2*i+1]] }
+		closing := group(1) == "/"
+		name := strings.ToUpper(group(2))
+		value := strings.TrimSpace(group(3))
+
+		switch {
+		case closing && name == lastLeaf:
+			// Fechamento de campo em OFX XML
+			lastLeaf = ""
+		case closing:
+			elements = append(elements, ofxElement{kind: ofxEnd, name: name, line: line})
+			lastLeaf = ""
+		case value != "":
+			elements = append(elements, ofxElement{kind: ofxLeaf, name: name, value: unescapeOFX(value), line: line})
+			lastLeaf = name
+		default:
+			elements = append(elements, ofxElement{kind: ofxStart, name: name, line: line})
+			lastLeaf = ""
+		}
+	}
+
+	return elements, nil
+}
+
+var ofxEntities = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&", "&quot;", `"`, "&apos;", "'", "&nbsp;", " ")
+
+func unescapeOFX(s string) string {
+	return ofxEntities.Replace(s)
+}
+
+// parseOFXDate converte datas OFX (AAAAMMDD[HHMMSS[.XXX]][[-3:BRT]]) considerando apenas o dia
+func parseOFXDate(dateStr string) (time.Time, error) {
+	dateStr = strings.TrimSpace(dateStr)
+	if len(dateStr) < 8 {
+		return time.Time{}, fmt.Errorf("unable to parse date: %q", dateStr)
+	}
+	return time.Parse("20060102", dateStr[:8])
+}
+
+// parseOFXAmount converte valores OFX (ponto ou vírgula como separador decimal)
+func parseOFXAmount(amountStr string) (money.Centavos, error) {
+	return money.ParseDecimal(strings.ReplaceAll(strings.TrimSpace(amountStr), ",", "."))
+}
+
+// normalizeOFXAccount remove separadores do número da conta (ex: "61159322-6" -> "611593226").
+// A busca da conta no banco também compara só os dígitos, qualquer que seja o formato do cadastro.
+func normalizeOFXAccount(account string) string {
+	return regexp.MustCompile(`\D`).ReplaceAllString(account, "")
+}
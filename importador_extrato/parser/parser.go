@@ -1,23 +1,41 @@
 package parser
 
-import "time"
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
+)
 
 // Statement representa um extrato bancário ou documento fiscal
 type Statement struct {
 	AccountNumber string
+	Agency        string
 	Period        string
-	Balance       float64
+	Balance       money.Centavos
 	Transactions  []Transaction
+	DailyBalances []DailyBalance // saldos de fim de dia informados pelo banco, em ordem cronológica
 	DasDocumento  *DasDocumento
+	Diagnostics   []Diagnostic // problemas encontrados nas linhas do arquivo; veja Diagnostic
 }
 
 // Transaction representa uma transação financeira
 type Transaction struct {
-	Date        time.Time
-	Description string
-	Details     string
-	Amount      float64
-	Balance     float64
+	ID           string // identificador da transação no banco (FITID do OFX, Identificador do Nubank)
+	Line         int    // linha do arquivo onde a transação começa (0 em formatos sem linhas, como PDF)
+	Date         time.Time
+	Description  string
+	Details      string
+	Amount       money.Centavos
+	Balance      money.Centavos
+	Counterparty *Counterparty // outra parte da transferência ou pagamento, quando a descrição a identifica
+}
+
+// DailyBalance é o saldo da conta ao fim do dia, como informado pelo banco
+// (coluna Saldo do Inter, LEDGERBAL do OFX)
+type DailyBalance struct {
+	Date    time.Time
+	Balance money.Centavos
 }
 
 // DasDocumento representa um documento DAS do Simples Nacional
@@ -26,16 +44,25 @@ type DasDocumento struct {
 	PeriodoApuracao time.Time
 	DataVencimento  time.Time
 	NumeroDocumento string
-	ValorTotal      float64
+	ValorTotal      money.Centavos
+}
+
+// loggerOrDefault retorna o logger informado ou, se nil, o logger padrão do slog
+func loggerOrDefault(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
 }
 
 // Parser é a interface que todos os parsers devem implementar
 type Parser interface {
-	// Parse processa o arquivo e retorna um Statement
-	Parse(filename string) (*Statement, error)
+	// Parse processa o arquivo e retorna um Statement. Registros ilegíveis não interrompem
+	// a leitura: ficam fora do extrato e são descritos em Statement.Diagnostics.
+	Parse(in *Input) (*Statement, error)
 
-	// CanParse verifica se o parser pode processar o arquivo
-	CanParse(filename string) bool
+	// Detect analisa o conteúdo do arquivo e indica a confiança de que o parser consegue processá-lo
+	Detect(sample *Sample) Detection
 
 	// GetName retorna o nome do parser (ex: "Inter", "Nubank", "Simples Nacional")
 	GetName() string
@@ -0,0 +1,44 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// extractPDFText extrai o texto de todas as páginas de um PDF.
+// Páginas ilegíveis são ignoradas e retornadas em pageErrors.
+func extractPDFText(in *Input) (text string, pageErrors []error, err error) {
+	ra, size, err := in.readerAt()
+	if err != nil {
+		return "", nil, err
+	}
+
+	r, err := pdf.NewReader(ra, size)
+	if err != nil {
+		return "", nil, fmt.Errorf("error opening PDF: %v", err)
+	}
+
+	var fullText strings.Builder
+
+	// Extrai texto de todas as páginas
+	totalPages := r.NumPage()
+	for pageNum := 1; pageNum <= totalPages; pageNum++ {
+		page := r.Page(pageNum)
+		if page.V.IsNull() {
+			continue
+		}
+
+		pageText, err := page.GetPlainText(nil)
+		if err != nil {
+			pageErrors = append(pageErrors, fmt.Errorf("error reading page %d: %v", pageNum, err))
+			continue
+		}
+
+		fullText.WriteString(pageText)
+		fullText.WriteString("\n")
+	}
+
+	return fullText.String(), pageErrors, nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/parser"
+)
+
+// runParsers lista os parsers registrados, na ordem em que são avaliados na detecção
+func runParsers(args []string) int {
+	fs := newFlagSet("parsers", "")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	for _, name := range parser.NewParserFactory(logger).ListSupportedParsers() {
+		fmt.Println(name)
+	}
+
+	return exitOK
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/db"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/reconcile"
+)
+
+// runReconcile concilia os documentos DAS com os débitos bancários: liga cada documento ao débito
+// que o pagou e marca-o como PAGO, marca como VENCIDO o que passou do vencimento sem pagamento e
+// lista os débitos que parecem DAS mas não correspondem a nenhum documento. Com -dry-run só mostra
+// o resultado.
+func runReconcile(args []string) int {
+	fs := newFlagSet("reconcile", "")
+	fromStr := fs.String("from", "", "data inicial dos pagamentos (dd/mm/aaaa); padrão: todas as transações")
+	toStr := fs.String("to", "", "data final dos pagamentos (dd/mm/aaaa); padrão: todas as transações")
+	window := fs.Int("window", 5, "número máximo de dias entre o vencimento e o pagamento")
+	dryRun := fs.Bool("dry-run", false, "mostra a conciliação, sem gravar")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 || *window < 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	from := time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+	var err error
+	if *fromStr != "" {
+		if from, err = time.Parse("02/01/2006", *fromStr); err != nil {
+			logger.Error("invalid -from date", "error", err)
+			return exitUsage
+		}
+	}
+	if *toStr != "" {
+		if to, err = time.Parse("02/01/2006", *toStr); err != nil {
+			logger.Error("invalid -to date", "error", err)
+			return exitUsage
+		}
+	}
+
+	_, database, err := connect()
+	if err != nil {
+		logger.Error("database unavailable", "error", err)
+		return exitConfig
+	}
+	defer database.Close()
+
+	documentos, err := database.ListDasDocumentos()
+	if err != nil {
+		logger.Error("error listing DAS documents", "error", err)
+		return exitFailure
+	}
+	debitos, err := database.ListDebitosSemDas(from, to)
+	if err != nil {
+		logger.Error("error listing transactions", "error", err)
+		return exitFailure
+	}
+
+	payments := make([]reconcile.Payment, len(debitos))
+	contas := map[string]string{} // transação id -> nome da conta
+	for i, d := range debitos {
+		payments[i] = reconcile.Payment{Transaction: d.Transaction, EmpresaID: d.EmpresaID}
+		contas[d.ID] = d.ContaNome
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	result := reconcile.Reconcile(documentos, payments, today, *window)
+	printReconciliation(result, contas)
+
+	if *dryRun {
+		fmt.Printf("\nDocumentos que seriam marcados como pagos: %d; vencidos: %d\n", len(result.Pagos), len(result.Vencidos))
+		return exitOK
+	}
+
+	pagamentos := make([]db.DasPagamento, len(result.Pagos))
+	for i, m := range result.Pagos {
+		pagamentos[i] = db.DasPagamento{DocumentoID: m.Documento.ID, TransacaoID: m.Pagamento.Transaction.ID}
+	}
+	vencidos := make([]string, len(result.Vencidos))
+	for i, d := range result.Vencidos {
+		vencidos[i] = d.ID
+	}
+
+	pagos, vencidosAtualizados, err := database.ConciliarDas(pagamentos, vencidos)
+	if err != nil {
+		logger.Error("error recording DAS reconciliation", "constraint", db.ConstraintName(err), "error", err)
+		return exitFailure
+	}
+
+	fmt.Printf("\nDocumentos marcados como pagos: %d; vencidos: %d\n", pagos, vencidosAtualizados)
+	return exitOK
+}
+
+// printReconciliation mostra os documentos pagos e vencidos e os débitos de DAS sem documento
+func printReconciliation(result *reconcile.Result, contas map[string]string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "Documentos pagos:")
+	fmt.Fprintln(w, "DOCUMENTO\tVENCIMENTO\tVALOR\tPAGAMENTO\tVALOR PAGO\tCONTA\tCRITÉRIO")
+	for _, m := range result.Pagos {
+		criterio := "valor e data"
+		if m.PorNumero {
+			criterio = "número do documento"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			m.Documento.NumeroDocumento,
+			m.Documento.DataVencimento.Format("02/01/2006"),
+			m.Documento.ValorTotal.FormatBR(),
+			m.Pagamento.Transaction.Data.Format("02/01/2006"),
+			m.Pagamento.Transaction.Valor.FormatBR(),
+			contas[m.Pagamento.Transaction.ID],
+			criterio,
+		)
+	}
+
+	fmt.Fprintln(w, "\nDocumentos vencidos sem pagamento:")
+	fmt.Fprintln(w, "DOCUMENTO\tVENCIMENTO\tVALOR")
+	for _, d := range result.Vencidos {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", d.NumeroDocumento, d.DataVencimento.Format("02/01/2006"), d.ValorTotal.FormatBR())
+	}
+
+	fmt.Fprintln(w, "\nPagamentos que parecem DAS sem documento correspondente:")
+	fmt.Fprintln(w, "DATA\tVALOR\tCONTA\tTÍTULO\tDESCRIÇÃO")
+	for _, p := range result.SemDocumento {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			p.Transaction.Data.Format("02/01/2006"),
+			p.Transaction.Valor.FormatBR(),
+			contas[p.Transaction.ID],
+			p.Transaction.Titulo,
+			valueOr(&p.Transaction.Descricao, "-"),
+		)
+	}
+	w.Flush()
+}
@@ -0,0 +1,155 @@
+// Package reconcile concilia os documentos DAS com os débitos bancários que os pagaram
+package reconcile
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+)
+
+// Payment é um débito bancário, com a empresa titular da conta
+type Payment struct {
+	Transaction models.Transaction
+	EmpresaID   string
+}
+
+// Match liga um documento DAS ao débito que o pagou
+type Match struct {
+	Documento *models.DasDocumento
+	Pagamento *Payment
+	PorNumero bool // a descrição do débito traz o número do documento
+}
+
+// Result é o resultado da conciliação
+type Result struct {
+	Pagos        []Match
+	Vencidos     []*models.DasDocumento // não pagos e com o vencimento já passado
+	SemDocumento []*Payment             // débitos que parecem pagamento de DAS, sem documento correspondente
+}
+
+// dasRegex identifica as descrições de pagamento de DAS
+var dasRegex = regexp.MustCompile(`(?i)\bDAS\b|simples\s+nacional|receita\s+federal`)
+
+// LooksLikeDAS indica se a descrição do débito parece o pagamento de um DAS
+func LooksLikeDAS(t *models.Transaction) bool {
+	return dasRegex.MatchString(t.Titulo) || dasRegex.MatchString(t.Descricao)
+}
+
+// Reconcile encontra o débito que pagou cada documento EMITIDO ou VENCIDO:
+//   - o débito cuja descrição traz o número do documento (como o código de barras pago), qualquer que
+//     seja o valor ou a data, já que o pagamento atrasado inclui multa e juros;
+//   - senão, um débito da mesma empresa, com o valor do documento e descrição de DAS, feito entre o
+//     mês seguinte ao período de apuração (quando o DAS pode ser emitido) e window dias depois do
+//     vencimento (que se estende quando cai em feriado ou fim de semana). Havendo mais de um, o mais
+//     próximo do vencimento.
+//
+// Cada débito paga no máximo um documento. Os documentos EMITIDOS sem pagamento e vencidos antes de
+// today são marcados como vencidos.
+func Reconcile(documentos []models.DasDocumento, payments []Payment, today time.Time, window int) *Result {
+	var pendentes []*models.DasDocumento
+	for i := range documentos {
+		d := &documentos[i]
+		if d.Status == models.DasEmitido || d.Status == models.DasVencido {
+			pendentes = append(pendentes, d)
+		}
+	}
+	slices.SortFunc(pendentes, func(a, b *models.DasDocumento) int {
+		return cmp.Or(a.DataVencimento.Compare(b.DataVencimento), cmp.Compare(a.NumeroDocumento, b.NumeroDocumento))
+	})
+
+	var debitos []*Payment
+	for i := range payments {
+		if payments[i].Transaction.TipoOperacao == "debito" {
+			debitos = append(debitos, &payments[i])
+		}
+	}
+
+	result := &Result{}
+	used := map[*Payment]bool{}
+	paid := map[*models.DasDocumento]bool{}
+
+	// O número do documento identifica o pagamento sem ambiguidade
+	for _, d := range pendentes {
+		for _, p := range debitos {
+			if !used[p] && containsNumero(&p.Transaction, d.NumeroDocumento) {
+				used[p], paid[d] = true, true
+				result.Pagos = append(result.Pagos, Match{Documento: d, Pagamento: p, PorNumero: true})
+				break
+			}
+		}
+	}
+
+	for _, d := range pendentes {
+		if paid[d] {
+			continue
+		}
+
+		from := d.PeriodoApuracao.AddDate(0, 1, 0)
+		to := d.DataVencimento.AddDate(0, 0, window)
+		var best *Payment
+		for _, p := range debitos {
+			t := &p.Transaction
+			if used[p] || p.EmpresaID != d.EmpresaID || t.Valor != d.ValorTotal || !LooksLikeDAS(t) ||
+				t.Data.Before(from) || t.Data.After(to) {
+				continue
+			}
+			if best == nil || distance(t.Data, d.DataVencimento) < distance(best.Transaction.Data, d.DataVencimento) {
+				best = p
+			}
+		}
+		if best != nil {
+			used[best], paid[d] = true, true
+			result.Pagos = append(result.Pagos, Match{Documento: d, Pagamento: best})
+			continue
+		}
+
+		if d.Status == models.DasEmitido && d.DataVencimento.Before(today) {
+			result.Vencidos = append(result.Vencidos, d)
+		}
+	}
+
+	slices.SortFunc(result.Pagos, func(a, b Match) int {
+		return cmp.Or(
+			a.Documento.DataVencimento.Compare(b.Documento.DataVencimento),
+			cmp.Compare(a.Documento.NumeroDocumento, b.Documento.NumeroDocumento),
+		)
+	})
+
+	for _, p := range debitos {
+		if !used[p] && LooksLikeDAS(&p.Transaction) {
+			result.SemDocumento = append(result.SemDocumento, p)
+		}
+	}
+
+	return result
+}
+
+// containsNumero indica se a descrição do débito traz o número do documento, com ou sem máscara
+func containsNumero(t *models.Transaction, numero string) bool {
+	if numero == "" {
+		return false
+	}
+	return strings.Contains(digits(t.Titulo), numero) || strings.Contains(digits(t.Descricao), numero)
+}
+
+// digits mantém só os dígitos do texto
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// distance é a distância, em tempo, entre duas datas
+func distance(a, b time.Time) time.Duration {
+	if a.After(b) {
+		return a.Sub(b)
+	}
+	return b.Sub(a)
+}
@@ -0,0 +1,107 @@
+package reconcile
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func documento(numero string, periodo time.Month, vencimento time.Time, valor money.Centavos, status string) models.DasDocumento {
+	return models.DasDocumento{
+		ID:              "das-" + numero,
+		EmpresaID:       "empresa",
+		PeriodoApuracao: date(2025, periodo, 1),
+		DataVencimento:  vencimento,
+		NumeroDocumento: numero,
+		ValorTotal:      valor,
+		Status:          status,
+	}
+}
+
+func pagamento(id string, data time.Time, valor money.Centavos, titulo, descricao string) Payment {
+	return Payment{
+		Transaction: models.Transaction{ID: id, Data: data, TipoOperacao: "debito", Valor: valor, Titulo: titulo, Descricao: descricao},
+		EmpresaID:   "empresa",
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	documentos := []models.DasDocumento{
+		// Pago em dia, pelo valor e pela descrição
+		documento("07202508000000001", time.August, date(2025, time.September, 22), 123456, models.DasEmitido),
+		// Pago com atraso (multa e juros): só o número do documento no código de barras identifica
+		documento("07202509000000002", time.September, date(2025, time.October, 20), 100000, models.DasVencido),
+		// Não pago e vencido
+		documento("07202510000000003", time.October, date(2025, time.November, 20), 50000, models.DasEmitido),
+		// Não pago, ainda no prazo
+		documento("07202511000000004", time.November, date(2025, time.December, 22), 50000, models.DasEmitido),
+		// Já conciliado
+		documento("07202507000000005", time.July, date(2025, time.August, 20), 123456, models.DasPago),
+	}
+	payments := []Payment{
+		pagamento("em-dia", date(2025, time.September, 19), 123456, "Pagamento de boleto efetuado", "DAS Simples Nacional"),
+		pagamento("atrasado", date(2025, time.November, 3), 104580, "Pagamento de título", "Código de barras 85810000010458003282072025090000000021"),
+		// Mesmo valor do DAS de outubro, mas não é DAS
+		pagamento("fornecedor", date(2025, time.November, 18), 50000, "Pix enviado", "Fornecedor Exemplo"),
+		// Parece DAS, mas nenhum documento tem esse valor
+		pagamento("sem-documento", date(2025, time.December, 1), 77700, "Pagamento DAS", ""),
+	}
+
+	result := Reconcile(documentos, payments, date(2025, time.December, 10), 5)
+
+	var pagos []string
+	for _, m := range result.Pagos {
+		pagos = append(pagos, m.Documento.NumeroDocumento+"="+m.Pagamento.Transaction.ID)
+	}
+	if want := []string{"07202508000000001=em-dia", "07202509000000002=atrasado"}; !slices.Equal(pagos, want) {
+		t.Errorf("Pagos = %v, want %v", pagos, want)
+	}
+	if !result.Pagos[1].PorNumero {
+		t.Error("late payment should be matched by the document number")
+	}
+
+	var vencidos []string
+	for _, d := range result.Vencidos {
+		vencidos = append(vencidos, d.NumeroDocumento)
+	}
+	if want := []string{"07202510000000003"}; !slices.Equal(vencidos, want) {
+		t.Errorf("Vencidos = %v, want %v", vencidos, want)
+	}
+
+	var semDocumento []string
+	for _, p := range result.SemDocumento {
+		semDocumento = append(semDocumento, p.Transaction.ID)
+	}
+	if want := []string{"sem-documento"}; !slices.Equal(semDocumento, want) {
+		t.Errorf("SemDocumento = %v, want %v", semDocumento, want)
+	}
+}
+
+func TestReconcileDateWindow(t *testing.T) {
+	documentos := []models.DasDocumento{
+		documento("07202508000000001", time.August, date(2025, time.September, 22), 123456, models.DasEmitido),
+	}
+	payments := []Payment{
+		// Antes do fim do período de apuração: não pode ser o pagamento desse DAS
+		pagamento("cedo", date(2025, time.August, 25), 123456, "Pagamento DAS", ""),
+		pagamento("longe", date(2025, time.September, 2), 123456, "Pagamento DAS", ""),
+		pagamento("perto", date(2025, time.September, 24), 123456, "Pagamento DAS", ""),
+		// Além da tolerância após o vencimento
+		pagamento("tarde", date(2025, time.October, 1), 123456, "Pagamento DAS", ""),
+	}
+
+	result := Reconcile(documentos, payments, date(2025, time.October, 10), 5)
+	if len(result.Pagos) != 1 || result.Pagos[0].Pagamento.Transaction.ID != "perto" {
+		t.Fatalf("Pagos = %+v, want the payment closest to the due date", result.Pagos)
+	}
+	if len(result.SemDocumento) != 3 {
+		t.Errorf("SemDocumento = %d, want 3", len(result.SemDocumento))
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// runReport mostra créditos, débitos e saldo das transações importadas por conta e mês
+func runReport(args []string) int {
+	fs := newFlagSet("report", "")
+	fromStr := fs.String("from", "", "data inicial (dd/mm/aaaa); padrão: início do ano corrente")
+	toStr := fs.String("to", "", "data final (dd/mm/aaaa); padrão: hoje")
+	account := fs.String("account", "", "número da conta; padrão: todas")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var err error
+	if *fromStr != "" {
+		if from, err = time.Parse("02/01/2006", *fromStr); err != nil {
+			logger.Error("invalid -from date", "error", err)
+			return exitUsage
+		}
+	}
+	if *toStr != "" {
+		if to, err = time.Parse("02/01/2006", *toStr); err != nil {
+			logger.Error("invalid -to date", "error", err)
+			return exitUsage
+		}
+	}
+
+	_, database, err := connect()
+	if err != nil {
+		logger.Error("database unavailable", "error", err)
+		return exitConfig
+	}
+	defer database.Close()
+
+	resumo, err := database.MonthlySummary(from, to, *account)
+	if err != nil {
+		logger.Error("error building report", "error", err)
+		return exitFailure
+	}
+
+	fmt.Printf("Transações de %s a %s\n\n", from.Format("02/01/2006"), to.Format("02/01/2006"))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "MÊS\tCONTA\tQTD\tCRÉDITOS\tDÉBITOS\tSALDO\t")
+	for _, r := range resumo {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t\n",
+			r.Mes.Format("01/2006"),
+			r.Conta,
+			r.Quantidade,
+			r.Creditos.FormatBR(),
+			r.Debitos.FormatBR(),
+			r.Saldo().FormatBR(),
+		)
+	}
+	w.Flush()
+
+	return exitOK
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/db"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+)
+
+// runImports lista as importações mais recentes
+func runImports(args []string) int {
+	fs := newFlagSet("imports", "")
+	limit := fs.Int("limit", 20, "quantidade máxima de importações listadas")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	_, database, err := connect()
+	if err != nil {
+		logger.Error("database unavailable", "error", err)
+		return exitConfig
+	}
+	defer database.Close()
+
+	importacoes, err := database.ListImportacoes(*limit)
+	if err != nil {
+		logger.Error("error listing imports", "error", err)
+		return exitFailure
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tINICIADO EM\tARQUIVO\tPARSER\tSTATUS\tIMPORTADAS\tIGNORADAS\tFALHAS")
+	for _, imp := range importacoes {
+		parserName := "-"
+		if imp.Parser != nil {
+			parserName = *imp.Parser
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\n",
+			imp.ID,
+			imp.IniciadoEm.Local().Format("02/01/2006 15:04"),
+			imp.ArquivoNome,
+			parserName,
+			imp.Status,
+			imp.QtdImportadas,
+			imp.QtdIgnoradas,
+			imp.QtdFalhas,
+		)
+	}
+	w.Flush()
+
+	return exitOK
+}
+
+// runRevert desfaz uma importação, removendo as transações e documentos DAS que ela criou
+func runRevert(args []string) int {
+	fs := newFlagSet("revert", "<importacao-id>")
+	dryRun := fs.Bool("dry-run", false, "apenas lista os registros que seriam removidos")
+	force := fs.Bool("force", false, "reverte mesmo registros modificados depois da importação")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	importacaoID := fs.Arg(0)
+
+	_, database, err := connect()
+	if err != nil {
+		logger.Error("database unavailable", "error", err)
+		return exitConfig
+	}
+	defer database.Close()
+
+	if *dryRun {
+		plan, err := database.PlanRevert(importacaoID)
+		if err != nil {
+			logger.Error("error planning revert", "importacao_id", importacaoID, "error", err)
+			return exitFailure
+		}
+		printRevertPlan(plan)
+		if err := plan.CheckRevertable(); err != nil {
+			fmt.Printf("\nA importação não pode ser revertida: %v\n", err)
+			return exitFailure
+		}
+		if plan.Modificados > 0 && !*force {
+			fmt.Printf("\n%d registro(s) modificado(s) depois da importação; use -force para reverter\n", plan.Modificados)
+		}
+		return exitOK
+	}
+
+	plan, err := database.RevertImportacao(importacaoID, *force)
+	if err != nil {
+		if plan != nil {
+			printRevertPlan(plan)
+		}
+		logger.Error("error reverting import", "importacao_id", importacaoID, "error", err)
+		return exitFailure
+	}
+
+	fmt.Printf("✓ Importação %s revertida: %d transação(ões) e %d documento(s) DAS removidos\n",
+		importacaoID, len(plan.Transacoes), len(plan.DasDocumentos))
+
+	return exitOK
+}
+
+func printRevertPlan(plan *db.RevertPlan) {
+	fmt.Printf("Importação %s (%s, %s)\n", plan.Importacao.ID, plan.Importacao.ArquivoNome, plan.Importacao.Status)
+	fmt.Printf("Transações a remover: %d\n", len(plan.Transacoes))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, t := range plan.Transacoes {
+		modificado := ""
+		if t.Modificada() {
+			modificado = "modificada"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n",
+			t.ID, t.Data.Format("02/01/2006"), t.TipoOperacao, t.Valor.FormatBR(), t.Titulo, modificado)
+	}
+	w.Flush()
+
+	fmt.Printf("Documentos DAS a remover: %d\n", len(plan.DasDocumentos))
+	for _, d := range plan.DasDocumentos {
+		modificado := ""
+		if d.Modificado() {
+			modificado = "modificado"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n",
+			d.ID, d.PeriodoApuracao.Format("01/2006"), d.NumeroDocumento, d.ValorTotal.FormatBR(), d.Status, modificado)
+	}
+	w.Flush()
+
+	if len(plan.DasPagos) > 0 {
+		fmt.Printf("Documentos DAS pagos por estas transações, que voltam a %s: %d\n", models.DasEmitido, len(plan.DasPagos))
+		for _, d := range plan.DasPagos {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n",
+				d.ID, d.PeriodoApuracao.Format("01/2006"), d.NumeroDocumento, d.ValorTotal.FormatBR(), d.Status)
+		}
+		w.Flush()
+	}
+
+	if len(plan.Transferencias) > 0 {
+		fmt.Printf("Transferências internas a desfazer: %d\n", len(plan.Transferencias))
+		for _, t := range plan.Transferencias {
+			fmt.Fprintf(w, "  %s\tdébito %s\tcrédito %s\n", t.ID, t.DebitoID, t.CreditoID)
+		}
+		w.Flush()
+	}
+}
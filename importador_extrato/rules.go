@@ -0,0 +1,367 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/db"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/parser"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/rules"
+)
+
+// runRules lista as regras de categorização, na ordem em que são avaliadas
+func runRules(args []string) int {
+	fs := newFlagSet("rules", "")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	_, database, err := connect()
+	if err != nil {
+		logger.Error("database unavailable", "error", err)
+		return exitConfig
+	}
+	defer database.Close()
+
+	regras, err := database.ListRegrasCategorizacao()
+	if err != nil {
+		logger.Error("error listing rules", "error", err)
+		return exitFailure
+	}
+
+	// Regras com expressão regular inválida impedem a categorização
+	code := exitOK
+	if _, err := rules.NewEngine(regras); err != nil {
+		logger.Error("invalid rule", "error", err)
+		code = exitFailure
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PRIORIDADE\tNOME\tCRITÉRIOS\tCATEGORIA\tSUBCATEGORIA\tPLANO\tATIVA")
+	for _, r := range regras {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Prioridade,
+			r.Nome,
+			describeCriteria(r),
+			r.Categoria,
+			valueOr(r.Subcategoria, "-"),
+			valueOr(r.CodigoPlano, "-"),
+			yesNo(r.Ativa),
+		)
+	}
+	w.Flush()
+
+	return code
+}
+
+// describeCriteria resume os critérios informados na regra
+func describeCriteria(r models.RegraCategorizacao) string {
+	var criteria []string
+	if r.TituloRegex != nil {
+		criteria = append(criteria, fmt.Sprintf("título ~ %s", *r.TituloRegex))
+	}
+	if r.DescricaoRegex != nil {
+		criteria = append(criteria, fmt.Sprintf("descrição ~ %s", *r.DescricaoRegex))
+	}
+	if r.Documento != nil {
+		criteria = append(criteria, "documento "+*r.Documento)
+	}
+	if r.ValorMin != nil {
+		criteria = append(criteria, "valor >= "+r.ValorMin.FormatBR())
+	}
+	if r.ValorMax != nil {
+		criteria = append(criteria, "valor <= "+r.ValorMax.FormatBR())
+	}
+	if r.ContaID != nil {
+		criteria = append(criteria, "conta "+*r.ContaID)
+	}
+	if r.TipoOperacao != nil {
+		criteria = append(criteria, *r.TipoOperacao)
+	}
+	if len(criteria) == 0 {
+		return "(todas)"
+	}
+	return strings.Join(criteria, "; ")
+}
+
+// runCategorize aplica as regras de categorização às transações já gravadas, para que mudanças
+// nas regras valham também para o histórico. Com -dry-run ou -file mostra a regra que combina
+// com cada transação, sem gravar.
+func runCategorize(args []string) int {
+	var files stringList
+	fs := newFlagSet("categorize", "")
+	fromStr := fs.String("from", "", "data inicial (dd/mm/aaaa); padrão: todas as transações")
+	toStr := fs.String("to", "", "data final (dd/mm/aaaa); padrão: todas as transações")
+	account := fs.String("account", "", "número da conta; padrão: todas")
+	dryRun := fs.Bool("dry-run", false, "mostra a regra que combina com cada transação, sem gravar")
+	fs.Var(&files, "file", "testa as regras nas transações deste extrato, sem gravar; pode ser repetido")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	from := time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+	var err error
+	if *fromStr != "" {
+		if from, err = time.Parse("02/01/2006", *fromStr); err != nil {
+			logger.Error("invalid -from date", "error", err)
+			return exitUsage
+		}
+	}
+	if *toStr != "" {
+		if to, err = time.Parse("02/01/2006", *toStr); err != nil {
+			logger.Error("invalid -to date", "error", err)
+			return exitUsage
+		}
+	}
+
+	_, database, err := connect()
+	if err != nil {
+		logger.Error("database unavailable", "error", err)
+		return exitConfig
+	}
+	defer database.Close()
+
+	regras, err := database.ListRegrasCategorizacao()
+	if err != nil {
+		logger.Error("error listing rules", "error", err)
+		return exitFailure
+	}
+	engine, err := rules.NewEngine(regras)
+	if err != nil {
+		logger.Error("invalid rule", "error", err)
+		return exitFailure
+	}
+
+	if len(files) > 0 {
+		return testRulesOnFiles(database, engine, files)
+	}
+
+	transacoes, err := database.ListTransactions(from, to, *account)
+	if err != nil {
+		logger.Error("error listing transactions", "error", err)
+		return exitFailure
+	}
+
+	result, err := categorize(engine, newPlanoContasCache(database), transacoes)
+	if err != nil {
+		logger.Error("error categorizing transactions", "error", err)
+		return exitFailure
+	}
+	if *dryRun {
+		printCategorization(os.Stdout, result)
+		fmt.Printf("\nTransações que seriam alteradas: %d\n", len(result.changed))
+		return exitOK
+	}
+
+	updated, err := database.UpdateCategorias(result.changed)
+	if err != nil {
+		logger.Error("error updating categories", "error", err)
+		return exitFailure
+	}
+
+	fmt.Printf("Transações: %d (categorizadas: %d, sem regra: %d, manuais: %d)\n",
+		len(result.rows), result.matched, result.unmatched, result.manual)
+	fmt.Printf("Transações alteradas: %d\n", updated)
+
+	return exitOK
+}
+
+// testRulesOnFiles mostra a regra que combina com cada transação dos extratos, sem gravar
+func testRulesOnFiles(repo db.Repository, engine *rules.Engine, files []string) int {
+	factory := parser.NewParserFactory(logger)
+	planos := newPlanoContasCache(repo)
+
+	code := exitOK
+	for _, path := range files {
+		parsed, err := parseFile(factory, path, importOptions{})
+		if err != nil {
+			logger.Error("error reading file", "file", path, "error", err)
+			code = exitFailure
+			continue
+		}
+		stmt := parsed.Statement
+
+		// Sem a conta cadastrada, as regras restritas a uma conta não combinam
+		contaID, err := repo.GetContaIDByNumero(stmt.AccountNumber)
+		if err != nil {
+			logger.Warn("account not found - rules restricted to an account will not match", "file", path, "account", stmt.AccountNumber)
+		}
+
+		var transacoes []models.Transaction
+		for _, t := range newTransactions("", contaID, stmt) {
+			transacoes = append(transacoes, *t)
+		}
+
+		fmt.Printf("=== %s (%s, conta %s) ===\n", path, parsed.Parser.GetName(), stmt.AccountNumber)
+		result, err := categorize(engine, planos, transacoes)
+		if err != nil {
+			logger.Error("error categorizing transactions", "file", path, "error", err)
+			code = exitFailure
+			continue
+		}
+		printCategorization(os.Stdout, result)
+		fmt.Println()
+	}
+
+	return code
+}
+
+// categorization é o resultado das regras aplicadas a um conjunto de transações
+type categorization struct {
+	rows      []categorizedRow
+	changed   []models.Transaction // transações cuja categoria mudou
+	matched   int
+	unmatched int
+	manual    int
+}
+
+// categorizedRow é uma transação e a regra que combinou com ela
+type categorizedRow struct {
+	transacao models.Transaction
+	regra     *models.RegraCategorizacao // nil se nenhuma regra combinou ou a categoria é manual
+	manual    bool
+}
+
+// categorize aplica as regras às transações. Transações categorizadas manualmente são mantidas.
+func categorize(engine *rules.Engine, planos *planoContasCache, transacoes []models.Transaction) (*categorization, error) {
+	result := &categorization{}
+	for _, t := range transacoes {
+		if t.CategorizadaManualmente() {
+			result.manual++
+			result.rows = append(result.rows, categorizedRow{transacao: t, manual: true})
+			continue
+		}
+
+		plano, err := planos.get(t.ContaID)
+		if err != nil {
+			return nil, err
+		}
+
+		before := categoryOf(&t)
+		regra := engine.Categorize(&t, plano)
+		if regra != nil {
+			result.matched++
+		} else {
+			result.unmatched++
+		}
+		if categoryOf(&t) != before {
+			result.changed = append(result.changed, t)
+		}
+		result.rows = append(result.rows, categorizedRow{transacao: t, regra: regra})
+	}
+	return result, nil
+}
+
+// categoryOf identifica a categoria atribuída, para detectar mudanças
+func categoryOf(t *models.Transaction) string {
+	return strings.Join([]string{
+		valueOr(t.Categoria, ""), valueOr(t.Subcategoria, ""), valueOr(t.RegraID, ""), valueOr(t.PlanoContaID, ""),
+	}, "\x00")
+}
+
+// planoContasCache carrega uma única vez o plano de contas da empresa de cada conta bancária
+type planoContasCache struct {
+	repo   db.Repository
+	planos map[string]rules.PlanoContas // conta id -> plano
+}
+
+func newPlanoContasCache(repo db.Repository) *planoContasCache {
+	return &planoContasCache{repo: repo, planos: map[string]rules.PlanoContas{}}
+}
+
+// get retorna o plano da conta; sem conta cadastrada (contaID vazio) o plano é vazio
+func (c *planoContasCache) get(contaID string) (rules.PlanoContas, error) {
+	if contaID == "" {
+		return nil, nil
+	}
+	if plano, ok := c.planos[contaID]; ok {
+		return plano, nil
+	}
+
+	contas, err := c.repo.ListPlanoContasByConta(contaID)
+	if err != nil {
+		return nil, err
+	}
+	c.planos[contaID] = rules.NewPlanoContas(contas)
+	return c.planos[contaID], nil
+}
+
+// printCategorization mostra a regra e a categoria de cada transação
+func printCategorization(w io.Writer, result *categorization) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATA\tVALOR\tTÍTULO\tDESCRIÇÃO\tREGRA\tCATEGORIA\tSUBCATEGORIA\tPLANO")
+	for _, row := range result.rows {
+		t := row.transacao
+		valor := t.Valor
+		if t.TipoOperacao == "debito" {
+			valor = -valor
+		}
+
+		regra, plano := "-", "-"
+		switch {
+		case row.manual:
+			regra = "(manual)"
+		case row.regra != nil:
+			regra = row.regra.Nome
+			if row.regra.CodigoPlano != nil {
+				// Código da regra sem conta correspondente no plano da empresa
+				plano = *row.regra.CodigoPlano + " (não encontrada)"
+				if t.PlanoContaID != nil {
+					plano = *row.regra.CodigoPlano
+				}
+			}
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			t.Data.Format("02/01/2006"),
+			valor.FormatBR(),
+			t.Titulo,
+			valueOr(&t.Descricao, "-"),
+			regra,
+			valueOr(t.Categoria, "-"),
+			valueOr(t.Subcategoria, "-"),
+			plano,
+		)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nTransações: %d (categorizadas: %d, sem regra: %d, manuais: %d)\n",
+		len(result.rows), result.matched, result.unmatched, result.manual)
+}
+
+// categorizeTransactions aplica as regras de categorização às transações importadas da conta
+func categorizeTransactions(repo db.Repository, contaID string, transacoes []*models.Transaction, flog *slog.Logger) error {
+	regras, err := repo.ListRegrasCategorizacao()
+	if err != nil {
+		return err
+	}
+	engine, err := rules.NewEngine(regras)
+	if err != nil {
+		return fmt.Errorf("error loading categorization rules: %v", err)
+	}
+	plano, err := newPlanoContasCache(repo).get(contaID)
+	if err != nil {
+		return err
+	}
+
+	var matched int
+	for _, t := range transacoes {
+		if engine.Categorize(t, plano) != nil {
+			matched++
+		}
+	}
+	flog.Info("transactions categorized", "rules", engine.Len(), "categorized", matched, "uncategorized", len(transacoes)-matched)
+
+	return nil
+}
@@ -0,0 +1,174 @@
+// Package rules categoriza as transações com as regras de financeiro.regras_categorizacao
+package rules
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+)
+
+// Engine avalia as regras ativas em ordem de prioridade; a primeira que combinar define a categoria
+type Engine struct {
+	rules []rule
+}
+
+// rule é uma regra com as expressões regulares já compiladas
+type rule struct {
+	*models.RegraCategorizacao
+	titulo    *regexp.Regexp
+	descricao *regexp.Regexp
+}
+
+// NewEngine compila as regras ativas. Regras com a mesma prioridade são avaliadas em ordem de nome.
+// Retorna erro se alguma expressão regular for inválida.
+func NewEngine(regras []models.RegraCategorizacao) (*Engine, error) {
+	e := &Engine{}
+	for i := range regras {
+		r := &regras[i]
+		if !r.Ativa {
+			continue
+		}
+
+		compiled := rule{RegraCategorizacao: r}
+		var err error
+		if compiled.titulo, err = compile(r.TituloRegex); err != nil {
+			return nil, fmt.Errorf("rule %q: invalid titulo_regex: %v", r.Nome, err)
+		}
+		if compiled.descricao, err = compile(r.DescricaoRegex); err != nil {
+			return nil, fmt.Errorf("rule %q: invalid descricao_regex: %v", r.Nome, err)
+		}
+		e.rules = append(e.rules, compiled)
+	}
+
+	slices.SortStableFunc(e.rules, func(a, b rule) int {
+		return cmp.Or(cmp.Compare(a.Prioridade, b.Prioridade), cmp.Compare(a.Nome, b.Nome))
+	})
+
+	return e, nil
+}
+
+func compile(expr *string) (*regexp.Regexp, error) {
+	if expr == nil || *expr == "" {
+		return nil, nil
+	}
+	return regexp.Compile(*expr)
+}
+
+// Len retorna o número de regras ativas
+func (e *Engine) Len() int {
+	return len(e.rules)
+}
+
+// Match retorna a primeira regra que combina com a transação, ou nil se nenhuma combinar
+func (e *Engine) Match(t *models.Transaction) *models.RegraCategorizacao {
+	var documentos []string
+	for _, r := range e.rules {
+		if r.Documento != nil && documentos == nil {
+			documentos = Documentos(t.Titulo + " " + t.Descricao)
+		}
+		if r.matches(t, documentos) {
+			return r.RegraCategorizacao
+		}
+	}
+	return nil
+}
+
+// Categorize define a categoria da transação pela regra que combinar e, se a regra indicar uma
+// conta do plano, a conta de mesmo código em plano. Sem regra, a categoria é removida.
+// Retorna a regra aplicada, ou nil.
+func (e *Engine) Categorize(t *models.Transaction, plano PlanoContas) *models.RegraCategorizacao {
+	t.Categoria, t.Subcategoria, t.RegraID, t.PlanoContaID = nil, nil, nil, nil
+
+	r := e.Match(t)
+	if r == nil {
+		return nil
+	}
+
+	categoria, id := r.Categoria, r.ID
+	t.Categoria, t.RegraID = &categoria, &id
+	if r.Subcategoria != nil {
+		subcategoria := *r.Subcategoria
+		t.Subcategoria = &subcategoria
+	}
+	if r.CodigoPlano != nil {
+		if planoContaID, ok := plano[*r.CodigoPlano]; ok {
+			t.PlanoContaID = &planoContaID
+		}
+	}
+	return r
+}
+
+// PlanoContas indexa pelo código as contas ativas do plano de contas de uma empresa
+type PlanoContas map[string]string
+
+// NewPlanoContas indexa as contas ativas do plano
+func NewPlanoContas(contas []models.PlanoConta) PlanoContas {
+	plano := PlanoContas{}
+	for _, c := range contas {
+		if c.Ativa {
+			plano[c.Codigo] = c.ID
+		}
+	}
+	return plano
+}
+
+func (r *rule) matches(t *models.Transaction, documentos []string) bool {
+	switch {
+	case r.ContaID != nil && *r.ContaID != t.ContaID:
+		return false
+	case r.TipoOperacao != nil && *r.TipoOperacao != t.TipoOperacao:
+		return false
+	case r.ValorMin != nil && t.Valor < *r.ValorMin:
+		return false
+	case r.ValorMax != nil && t.Valor > *r.ValorMax:
+		return false
+	case r.titulo != nil && !r.titulo.MatchString(t.Titulo):
+		return false
+	case r.descricao != nil && !r.descricao.MatchString(t.Descricao):
+		return false
+	case r.Documento != nil && !slices.ContainsFunc(documentos, func(d string) bool { return documentoMatches(d, *r.Documento) }):
+		return false
+	}
+	return true
+}
+
+// documentoRegex encontra CNPJs e CPFs, com ou sem máscara. O Nubank oculta parte do CPF com •••.
+var documentoRegex = regexp.MustCompile(`\d{2}\.?\d{3}\.?\d{3}/?\d{4}-?\d{2}|[\d•*]{3}\.[\d•*]{3}\.[\d•*]{3}-[\d•*]{2}|\b\d{11}\b`)
+
+// Documentos retorna os CNPJs e CPFs citados no texto, sem pontuação. Os dígitos ocultos de um CPF
+// mascarado são mantidos como '*'.
+func Documentos(text string) []string {
+	var documentos []string
+	for _, m := range documentoRegex.FindAllString(text, -1) {
+		d := strings.Map(func(r rune) rune {
+			switch {
+			case r >= '0' && r <= '9':
+				return r
+			case r == '•' || r == '*':
+				return '*'
+			}
+			return -1
+		}, m)
+		if strings.ContainsAny(d, "0123456789") {
+			documentos = append(documentos, d)
+		}
+	}
+	return documentos
+}
+
+// documentoMatches compara o documento do texto com o da regra; posições ocultas combinam com qualquer dígito
+func documentoMatches(documento, want string) bool {
+	if len(documento) != len(want) {
+		return false
+	}
+	for i := range len(documento) {
+		if documento[i] != '*' && documento[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
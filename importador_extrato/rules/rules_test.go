@@ -0,0 +1,101 @@
+package rules
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestMatch(t *testing.T) {
+	regras := []models.RegraCategorizacao{
+		{ID: "tarifas", Nome: "tarifas", Prioridade: 10, Ativa: true, TituloRegex: ptr(`(?i)^tarifa`), Categoria: "Despesas bancárias"},
+		{ID: "cliente", Nome: "cliente", Prioridade: 20, Ativa: true, Documento: ptr("00000000000100"), TipoOperacao: ptr("credito"), Categoria: "Receitas", Subcategoria: ptr("Vendas")},
+		{ID: "fulano", Nome: "fulano", Prioridade: 20, Ativa: true, Documento: ptr("12300000045"), Categoria: "Pessoal"},
+		{ID: "pix-grande", Nome: "pix grande", Prioridade: 30, Ativa: true, TituloRegex: ptr(`(?i)pix`), ValorMin: ptr(money.Centavos(100000)), Categoria: "Fornecedores"},
+		{ID: "conta", Nome: "conta", Prioridade: 40, Ativa: true, ContaID: ptr("conta-2"), Categoria: "Outra conta"},
+		{ID: "inativa", Nome: "inativa", Prioridade: 0, Ativa: false, Categoria: "Nunca"},
+	}
+	engine, err := NewEngine(regras)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		t    models.Transaction
+		want string // ID da regra; "" se nenhuma combinar
+	}{
+		{"title regex", models.Transaction{Titulo: "TARIFA MANUTENCAO", Valor: 1000, TipoOperacao: "debito"}, "tarifas"},
+		{"cnpj in description", models.Transaction{Titulo: "Transferência recebida pelo Pix - CLIENTE - 00.000.000/0001-00", Valor: 150000, TipoOperacao: "credito"}, "cliente"},
+		{"wrong direction", models.Transaction{Titulo: "Pix enviado", Descricao: "CLIENTE 00.000.000/0001-00", Valor: 500, TipoOperacao: "debito"}, ""},
+		{"masked cpf", models.Transaction{Titulo: "Transferência enviada pelo Pix - FULANO - •••.000.000-•• - BANCO", Valor: 10000, TipoOperacao: "debito"}, "fulano"},
+		{"amount range", models.Transaction{Titulo: "Pix enviado", Descricao: "Fornecedor", Valor: 120000, TipoOperacao: "debito"}, "pix-grande"},
+		{"below minimum", models.Transaction{Titulo: "Pix enviado", Descricao: "Fornecedor", Valor: 99999, TipoOperacao: "debito"}, ""},
+		{"conta", models.Transaction{ContaID: "conta-2", Titulo: "Pagamento", Valor: 100, TipoOperacao: "debito"}, "conta"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if r := engine.Match(&tt.t); r != nil {
+				got = r.ID
+			}
+			if got != tt.want {
+				t.Errorf("Match() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCategorize(t *testing.T) {
+	engine, err := NewEngine([]models.RegraCategorizacao{
+		{ID: "geral", Nome: "geral", Prioridade: 20, Ativa: true, Categoria: "Outros"},
+		{ID: "rendimento", Nome: "rendimento", Prioridade: 10, Ativa: true, TituloRegex: ptr(`^Rendimento`), Categoria: "Receitas financeiras", Subcategoria: ptr("Rendimentos"), CodigoPlano: ptr("5.1")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plano := NewPlanoContas([]models.PlanoConta{
+		{ID: "receitas-financeiras", Codigo: "5", Ativa: true},
+		{ID: "rendimentos", Codigo: "5.1", Ativa: true},
+	})
+
+	tr := models.Transaction{Titulo: "Rendimento", Valor: 12, TipoOperacao: "credito"}
+	if r := engine.Categorize(&tr, plano); r == nil || r.ID != "rendimento" {
+		t.Fatalf("Categorize() = %+v, want the higher priority rule", r)
+	}
+	if *tr.Categoria != "Receitas financeiras" || *tr.Subcategoria != "Rendimentos" || *tr.RegraID != "rendimento" {
+		t.Errorf("categoria = %v/%v (%v)", *tr.Categoria, *tr.Subcategoria, *tr.RegraID)
+	}
+	if tr.PlanoContaID == nil || *tr.PlanoContaID != "rendimentos" {
+		t.Errorf("plano_conta_id = %v, want rendimentos", tr.PlanoContaID)
+	}
+
+	tr.Titulo = "Pagamento"
+	engine.Categorize(&tr, plano)
+	if *tr.Categoria != "Outros" || tr.Subcategoria != nil || *tr.RegraID != "geral" || tr.PlanoContaID != nil {
+		t.Errorf("after recategorization categoria = %v, subcategoria = %v, plano_conta_id = %v", *tr.Categoria, tr.Subcategoria, tr.PlanoContaID)
+	}
+}
+
+func TestNewEngineInvalidRegex(t *testing.T) {
+	_, err := NewEngine([]models.RegraCategorizacao{
+		{Nome: "quebrada", Ativa: true, TituloRegex: ptr(`(`), Categoria: "Outros"},
+	})
+	if err == nil {
+		t.Error("NewEngine() accepted an invalid regex")
+	}
+}
+
+func TestDocumentos(t *testing.T) {
+	text := "Pix - CLIENTE - 00.000.000/0001-00 - FULANO - •••.456.789-•• - 12345678901 - 11222333000181"
+	want := []string{"00000000000100", "***456789**", "12345678901", "11222333000181"}
+	if got := Documentos(text); !slices.Equal(got, want) {
+		t.Errorf("Documentos() = %q, want %q", got, want)
+	}
+}
@@ -15,5 +15,5 @@ export DB_PASSWORD="postgres"
 export DB_NAME="wgtrade"
 
 # Build and run the importer
-go build -o importador
-./importador
+go build -o importador || exit 1
+./importador import -dir ./rawdata/extrato "$@"
@@ -13,7 +13,7 @@ NC='\033[0m' # No Color
 
 # Testa compilação
 echo "1. Testando compilação..."
-cd /home/lopes/llopes/wg/workspace/wgtradepromo-backend/importador_extrato
+cd "$(dirname "$0")"
 if go build -o importador_test 2>&1 | grep -q "error"; then
     echo -e "${RED}✗ Falha na compilação${NC}"
     exit 1
@@ -21,9 +21,21 @@ else
     echo -e "${GREEN}✓ Compilação bem-sucedida${NC}"
 fi
 
+# Compara a saída dos parsers com os arquivos golden em parser/testdata
+# (para regenerá-los: go test ./parser -run TestGolden -update)
+echo ""
+echo "2. Testando parsers com os arquivos de exemplo..."
+if go test ./parser -run TestGolden; then
+    echo -e "${GREEN}✓ Parsers reproduzem os arquivos golden${NC}"
+else
+    echo -e "${RED}✗ Saída dos parsers difere dos arquivos golden${NC}"
+    rm -f importador_test
+    exit 1
+fi
+
 # Lista arquivos disponíveis
 echo ""
-echo "2. Arquivos disponíveis para importação:"
+echo "3. Arquivos disponíveis para importação:"
 echo ""
 
 echo "Banco Inter (CSV):"
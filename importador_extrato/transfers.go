@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/db"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/transfers"
+)
+
+// runTransfers encontra as transferências entre as contas das nossas empresas e as grava em
+// financeiro.transferencias_internas, para que fiquem fora de fct_transacoes. Com -dry-run só
+// mostra os pares encontrados.
+func runTransfers(args []string) int {
+	fs := newFlagSet("transfers", "")
+	fromStr := fs.String("from", "", "data inicial (dd/mm/aaaa); padrão: todas as transações")
+	toStr := fs.String("to", "", "data final (dd/mm/aaaa); padrão: todas as transações")
+	window := fs.Int("window", 3, "número máximo de dias entre o débito e o crédito")
+	dryRun := fs.Bool("dry-run", false, "mostra as transferências encontradas, sem gravar")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 || *window < 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	from := time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+	var err error
+	if *fromStr != "" {
+		if from, err = time.Parse("02/01/2006", *fromStr); err != nil {
+			logger.Error("invalid -from date", "error", err)
+			return exitUsage
+		}
+	}
+	if *toStr != "" {
+		if to, err = time.Parse("02/01/2006", *toStr); err != nil {
+			logger.Error("invalid -to date", "error", err)
+			return exitUsage
+		}
+	}
+
+	_, database, err := connect()
+	if err != nil {
+		logger.Error("database unavailable", "error", err)
+		return exitConfig
+	}
+	defer database.Close()
+
+	candidatas, err := database.ListTransferenciaCandidatas(from, to)
+	if err != nil {
+		logger.Error("error listing transactions", "error", err)
+		return exitFailure
+	}
+
+	pairs := transfers.Match(newTransferCandidates(candidatas), *window)
+	printTransfers(os.Stdout, pairs)
+	if *dryRun {
+		fmt.Printf("\nTransferências que seriam gravadas: %d\n", len(pairs))
+		return exitOK
+	}
+
+	transferencias := make([]models.TransferenciaInterna, len(pairs))
+	for i, p := range pairs {
+		transferencias[i] = db.NewTransferenciaInterna(p.Debito.Transaction.ID, p.Credito.Transaction.ID)
+	}
+	inserted, err := database.InsertTransferenciasInternas(transferencias)
+	if err != nil {
+		logger.Error("error recording internal transfers", "error", err)
+		return exitFailure
+	}
+
+	fmt.Printf("\nTransferências encontradas: %d (gravadas: %d)\n", len(pairs), inserted)
+	return exitOK
+}
+
+// newTransferCandidates converte as transações do banco para o formato do matcher
+func newTransferCandidates(candidatas []db.TransferenciaCandidata) []transfers.Candidate {
+	candidates := make([]transfers.Candidate, len(candidatas))
+	for i, c := range candidatas {
+		candidates[i] = transfers.Candidate{
+			Transaction:          c.Transaction,
+			ContaNome:            c.ContaNome,
+			EmpresaNome:          c.EmpresaNome,
+			EmpresaCNPJ:          c.EmpresaCNPJ,
+			ContraparteNome:      valueOr(c.ContraparteNome, ""),
+			ContraparteDocumento: valueOr(c.ContraparteDocumento, ""),
+		}
+	}
+	return candidates
+}
+
+// printTransfers mostra o débito e o crédito de cada transferência interna
+func printTransfers(w io.Writer, pairs []transfers.Pair) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VALOR\tDÉBITO\tORIGEM\tCRÉDITO\tDESTINO\tTÍTULO")
+	for _, p := range pairs {
+		fmt.Fprintf(tw, "%s\t%s\t%s (%s)\t%s\t%s (%s)\t%s\n",
+			p.Debito.Transaction.Valor.FormatBR(),
+			p.Debito.Transaction.Data.Format("02/01/2006"),
+			p.Debito.ContaNome,
+			p.Debito.EmpresaNome,
+			p.Credito.Transaction.Data.Format("02/01/2006"),
+			p.Credito.ContaNome,
+			p.Credito.EmpresaNome,
+			p.Debito.Transaction.Titulo,
+		)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,159 @@
+// Package transfers encontra as transferências entre as contas das nossas empresas, que aparecem
+// como um débito em uma conta e um crédito de mesmo valor em outra
+package transfers
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+)
+
+// Candidate é uma transação com a empresa titular da conta e a contraparte extraída do extrato
+type Candidate struct {
+	Transaction          models.Transaction
+	ContaNome            string
+	EmpresaNome          string
+	EmpresaCNPJ          string
+	ContraparteNome      string
+	ContraparteDocumento string // sem máscara; dígitos ocultos como '*'
+}
+
+// Pair é uma transferência interna: o débito na conta de origem e o crédito na conta de destino
+type Pair struct {
+	Debito  *Candidate
+	Credito *Candidate
+	Dias    int // dias entre o débito e o crédito
+}
+
+// evidence é o que a contraparte de um lado diz sobre a empresa do outro lado
+type evidence int
+
+const (
+	unknown  evidence = iota // sem contraparte, ou só com CPF mascarado
+	matches                  // CNPJ ou nome da empresa do outro lado
+	mismatch                 // outro documento ou outro nome
+)
+
+// Match pareia débitos e créditos de mesmo valor em contas diferentes, com até window dias entre eles,
+// cujas contrapartes apontam para a empresa do outro lado: o débito enviado para o CNPJ (ou o nome)
+// da empresa que recebeu, o crédito recebido da empresa que enviou. Basta um dos lados identificar a
+// outra empresa, desde que o outro lado não identifique uma terceira.
+// Cada transação entra em no máximo um par; os pares com datas mais próximas têm preferência.
+func Match(candidates []Candidate, window int) []Pair {
+	var debitos, creditos []*Candidate
+	for i := range candidates {
+		c := &candidates[i]
+		switch c.Transaction.TipoOperacao {
+		case "debito":
+			debitos = append(debitos, c)
+		case "credito":
+			creditos = append(creditos, c)
+		}
+	}
+
+	var pairs []Pair
+	for _, d := range debitos {
+		for _, c := range creditos {
+			if d.Transaction.ContaID == c.Transaction.ContaID || d.Transaction.Valor != c.Transaction.Valor {
+				continue
+			}
+			dias := daysBetween(d, c)
+			if dias > window {
+				continue
+			}
+
+			sent, received := counterpartyOf(d, c), counterpartyOf(c, d)
+			if sent == mismatch || received == mismatch || (sent != matches && received != matches) {
+				continue
+			}
+			pairs = append(pairs, Pair{Debito: d, Credito: c, Dias: dias})
+		}
+	}
+
+	slices.SortStableFunc(pairs, func(a, b Pair) int {
+		return cmp.Or(
+			cmp.Compare(a.Dias, b.Dias),
+			a.Debito.Transaction.Data.Compare(b.Debito.Transaction.Data),
+			cmp.Compare(a.Debito.Transaction.ID, b.Debito.Transaction.ID),
+			cmp.Compare(a.Credito.Transaction.ID, b.Credito.Transaction.ID),
+		)
+	})
+
+	used := map[*Candidate]bool{}
+	var matched []Pair
+	for _, p := range pairs {
+		if used[p.Debito] || used[p.Credito] {
+			continue
+		}
+		used[p.Debito], used[p.Credito] = true, true
+		matched = append(matched, p)
+	}
+
+	slices.SortFunc(matched, func(a, b Pair) int {
+		return cmp.Or(
+			a.Debito.Transaction.Data.Compare(b.Debito.Transaction.Data),
+			cmp.Compare(a.Debito.Transaction.ID, b.Debito.Transaction.ID),
+		)
+	})
+	return matched
+}
+
+// daysBetween retorna o número de dias entre as datas das transações
+func daysBetween(a, b *Candidate) int {
+	hours := a.Transaction.Data.Sub(b.Transaction.Data).Hours()
+	if hours < 0 {
+		hours = -hours
+	}
+	return int(hours+12) / 24
+}
+
+// counterpartyOf compara a contraparte de t com a empresa titular da conta de other.
+// Um CPF/CNPJ completo decide; sem ele, vale o nome, que precisa ser igual ao da empresa (a menos
+// do tipo societário): nomes truncados ou que só compartilham o início, como "WG", não identificam
+// a empresa.
+func counterpartyOf(t, other *Candidate) evidence {
+	doc := t.ContraparteDocumento
+	if len(doc) == 14 && !strings.Contains(doc, "*") {
+		if doc == other.EmpresaCNPJ {
+			return matches
+		}
+		return mismatch
+	}
+	if len(doc) == 11 && !strings.Contains(doc, "*") {
+		// CPF: a outra parte é uma pessoa, não uma das nossas empresas
+		return mismatch
+	}
+
+	nome, empresa := normalizeName(t.ContraparteNome), normalizeName(other.EmpresaNome)
+	switch {
+	case nome == "" || empresa == "":
+		return unknown
+	case nome == empresa:
+		return matches
+	}
+	return mismatch
+}
+
+// tiposSocietarios são os sufixos que os bancos incluem ou omitem no nome das empresas
+var tiposSocietarios = []string{"LTDA", "ME", "EPP", "EIRELI", "SA", "S A"}
+
+// normalizeName deixa o nome em maiúsculas, só com letras, dígitos e espaços simples, sem o tipo
+// societário no final
+func normalizeName(nome string) string {
+	nome = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return ' '
+	}, nome)
+	nome = strings.Join(strings.Fields(nome), " ")
+	for _, tipo := range tiposSocietarios {
+		if base, ok := strings.CutSuffix(nome, " "+tipo); ok {
+			return base
+		}
+	}
+	return nome
+}
@@ -0,0 +1,111 @@
+package transfers
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/models"
+	"github.com/gabsdotdev/wgtradepromo-backend/importador_extrato/money"
+)
+
+const (
+	cnpjTrade   = "11111111000111"
+	cnpjEventos = "22222222000122"
+)
+
+func candidate(id, conta, tipo string, day int, valor money.Centavos, contraparte, documento string) Candidate {
+	c := Candidate{
+		Transaction: models.Transaction{
+			ID:           id,
+			ContaID:      conta,
+			Data:         time.Date(2025, time.January, day, 0, 0, 0, 0, time.UTC),
+			TipoOperacao: tipo,
+			Valor:        valor,
+		},
+		ContraparteNome:      contraparte,
+		ContraparteDocumento: documento,
+	}
+	switch conta {
+	case "trade-nubank", "trade-inter":
+		c.EmpresaNome, c.EmpresaCNPJ = "WG Trade Promo Ltda", cnpjTrade
+	default:
+		c.EmpresaNome, c.EmpresaCNPJ = "WG Eventos Ltda", cnpjEventos
+	}
+	return c
+}
+
+func TestMatch(t *testing.T) {
+	candidates := []Candidate{
+		// Trade -> Eventos pelo CNPJ, crédito no dia seguinte
+		candidate("d1", "trade-nubank", "debito", 5, 100000, "WG EVENTOS LTDA", cnpjEventos),
+		candidate("c1", "eventos-inter", "credito", 6, 100000, "WG TRADE PROMO", ""),
+		// Entre contas da mesma empresa, só o crédito identifica a origem, pelo nome
+		candidate("d2", "trade-inter", "debito", 10, 50000, "", ""),
+		candidate("c2", "trade-nubank", "credito", 10, 50000, "WG TRADE PROMO LTDA", ""),
+		// Mesmo valor, mas o débito foi para um fornecedor
+		candidate("d3", "trade-nubank", "debito", 12, 30000, "Fornecedor Exemplo", "33333333000133"),
+		candidate("c3", "eventos-inter", "credito", 12, 30000, "WG Trade Promo Ltda", cnpjTrade),
+		// Datas distantes demais
+		candidate("d4", "eventos-inter", "debito", 1, 20000, "WG Trade Promo Ltda", cnpjTrade),
+		candidate("c4", "trade-nubank", "credito", 20, 20000, "WG Eventos Ltda", cnpjEventos),
+		// Sem contraparte em nenhum dos lados
+		candidate("d5", "trade-nubank", "debito", 15, 7000, "", ""),
+		candidate("c5", "eventos-inter", "credito", 15, 7000, "", ""),
+		// Na mesma conta não há transferência
+		candidate("d6", "trade-nubank", "debito", 18, 9000, "WG Trade Promo Ltda", cnpjTrade),
+		candidate("c6", "trade-nubank", "credito", 18, 9000, "WG Trade Promo Ltda", cnpjTrade),
+	}
+
+	got := pairIDs(Match(candidates, 3))
+	want := [][2]string{{"d1", "c1"}, {"d2", "c2"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Match() = %v, want %v", got, want)
+	}
+}
+
+func TestMatchPrefersClosestDates(t *testing.T) {
+	candidates := []Candidate{
+		candidate("d1", "trade-nubank", "debito", 10, 100000, "WG Eventos", cnpjEventos),
+		candidate("d2", "trade-nubank", "debito", 12, 100000, "WG Eventos", cnpjEventos),
+		candidate("c1", "eventos-inter", "credito", 12, 100000, "WG Trade Promo", cnpjTrade),
+		candidate("c2", "eventos-inter", "credito", 13, 100000, "WG Trade Promo", cnpjTrade),
+	}
+
+	got := pairIDs(Match(candidates, 3))
+	want := [][2]string{{"d1", "c2"}, {"d2", "c1"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Match() = %v, want %v", got, want)
+	}
+}
+
+func TestMatchRequiresExactName(t *testing.T) {
+	candidates := []Candidate{
+		// Só o início do nome em comum com a empresa da outra conta
+		candidate("d1", "trade-inter", "debito", 10, 50000, "", ""),
+		candidate("c1", "eventos-inter", "credito", 10, 50000, "WG", ""),
+		// Nome truncado pelo banco
+		candidate("d2", "trade-inter", "debito", 12, 40000, "", ""),
+		candidate("c2", "eventos-inter", "credito", 12, 40000, "WG TRADE PROMO L", ""),
+		// Outra empresa cujo nome começa com o da nossa
+		candidate("d3", "eventos-inter", "debito", 14, 30000, "WG Eventos e Shows Ltda", ""),
+		candidate("c3", "trade-nubank", "credito", 14, 30000, "", ""),
+		// Mesmo nome, com tipo societário abreviado
+		candidate("d4", "eventos-inter", "debito", 16, 20000, "WG TRADE PROMO", ""),
+		candidate("c4", "trade-nubank", "credito", 16, 20000, "", ""),
+	}
+
+	got := pairIDs(Match(candidates, 3))
+	want := [][2]string{{"d4", "c4"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Match() = %v, want %v", got, want)
+	}
+}
+
+func pairIDs(pairs []Pair) [][2]string {
+	var ids [][2]string
+	for _, p := range pairs {
+		ids = append(ids, [2]string{p.Debito.Transaction.ID, p.Credito.Transaction.ID})
+	}
+	return ids
+}
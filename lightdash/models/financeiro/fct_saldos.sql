@@ -0,0 +1,47 @@
+{{ config(materialized='view') }}
+
+WITH s AS (
+  SELECT *
+  FROM {{ source('financeiro', 'saldos_diarios') }}
+),
+c AS (
+  SELECT *
+  FROM {{ source('financeiro', 'contas') }}
+),
+e AS (
+  SELECT *
+  FROM {{ source('cadastros', 'empresas') }}
+),
+-- Um dia por conta, do primeiro ao último saldo gravado
+dias AS (
+  SELECT p.conta_id, d::date AS data
+  FROM (
+    SELECT conta_id, MIN(data) AS inicio, MAX(data) AS fim
+    FROM s
+    GROUP BY conta_id
+  ) p
+  CROSS JOIN LATERAL generate_series(p.inicio, p.fim, INTERVAL '1 day') AS d
+)
+SELECT
+  dias.conta_id || '|' || dias.data AS id,
+  dias.conta_id,
+  c.empresa_id,
+  e.nome               AS empresa,
+  c.nome               AS conta,
+  dias.data,
+  ult.data             AS data_referencia,   -- último dia com saldo gravado (dias sem movimento repetem o saldo anterior)
+  ult.saldo_extrato,                         -- informado pelo banco; nulo se o extrato não traz saldo
+  ult.saldo_calculado,                       -- saldo inicial + transações
+  COALESCE(ult.saldo_extrato, ult.saldo_calculado) AS saldo,
+  ult.divergente
+FROM dias
+CROSS JOIN LATERAL (
+  SELECT *
+  FROM s
+  WHERE s.conta_id = dias.conta_id
+    AND s.data <= dias.data
+  ORDER BY s.data DESC
+  LIMIT 1
+) ult
+JOIN c ON c.id = dias.conta_id
+JOIN e ON e.id = c.empresa_id
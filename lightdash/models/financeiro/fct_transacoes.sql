@@ -11,6 +11,29 @@ c AS (
 e AS (
   SELECT *
   FROM {{ source('cadastros', 'empresas') }}
+),
+cp AS (
+  SELECT *
+  FROM {{ source('financeiro', 'contrapartes') }}
+),
+-- Débitos e créditos das transferências entre as nossas contas: ficam fora do fato
+ti AS (
+  SELECT debito_id AS transacao_id FROM {{ source('financeiro', 'transferencias_internas') }}
+  UNION ALL
+  SELECT credito_id FROM {{ source('financeiro', 'transferencias_internas') }}
+),
+-- Caminho de cada conta do plano desde a raiz: nomes[1] é o grupo da DRE
+p AS (
+  WITH RECURSIVE arvore AS (
+    SELECT id, codigo, natureza, ARRAY[nome::text] AS nomes
+    FROM {{ source('financeiro', 'plano_contas') }}
+    WHERE parent_id IS NULL
+    UNION ALL
+    SELECT f.id, f.codigo, f.natureza, a.nomes || f.nome::text
+    FROM {{ source('financeiro', 'plano_contas') }} f
+    JOIN arvore a ON a.id = f.parent_id
+  )
+  SELECT * FROM arvore
 )
 SELECT
   t.id,
@@ -23,6 +46,17 @@ SELECT
   t.descricao,
   t.tipo_operacao,           -- 'credito' | 'debito'
   t.tipo_transacao,
+  COALESCE(t.categoria, 'Sem categoria') AS categoria,
+  t.subcategoria,
+  t.regra_id,                -- NULL com categoria preenchida: categorização manual
+  p.codigo             AS plano_codigo,
+  p.natureza           AS plano_natureza,
+  COALESCE(p.nomes[1], 'Sem classificação') AS plano_nivel_1,
+  p.nomes[2]           AS plano_nivel_2,
+  p.nomes[3]           AS plano_nivel_3,
+  t.contraparte_id,
+  COALESCE(cp.nome, 'Não identificada') AS contraparte,
+  cp.documento         AS contraparte_documento,
   t.valor,                   -- sempre positivo (constraint)
   CASE
     WHEN t.tipo_operacao = 'credito' THEN t.valor
@@ -31,4 +65,7 @@ SELECT
   END AS valor_signed
 FROM t
 JOIN c ON c.id = t.conta_id
-JOIN e ON e.id = c.empresa_id
\ No newline at end of file
+JOIN e ON e.id = c.empresa_id
+LEFT JOIN p ON p.id = t.plano_conta_id
+LEFT JOIN cp ON cp.id = t.contraparte_id
+WHERE NOT EXISTS (SELECT 1 FROM ti WHERE ti.transacao_id = t.id)
\ No newline at end of file